@@ -1,21 +1,13 @@
 package check
 
 import (
-	"fmt"
-	"regexp"
-	"sort"
 	"time"
 
+	"github.com/ljfranklin/test-runner-resource/history"
 	"github.com/ljfranklin/test-runner-resource/models"
 	"github.com/ljfranklin/test-runner-resource/storage"
 )
 
-var (
-	keyRegex = regexp.MustCompile(`/?test-results-(.+)\.xml$`)
-	// e.g. "2006-01-02T15:04:05Z"
-	timeFormat = time.RFC3339
-)
-
 type Checker struct {
 	Storage storage.Storage
 }
@@ -24,21 +16,31 @@ func (c Checker) Check(startingVersion models.Version) (models.CheckResponse, er
 	var startingTimestamp time.Time
 	if startingVersion != (models.Version{}) {
 		var err error
-		startingTimestamp, err = keyToTimestamp(startingVersion.Key)
+		startingTimestamp, err = history.KeyToTimestamp(startingVersion.Key)
 		if err != nil {
 			return nil, err
 		}
 	}
 
+	if storage.IsDisabled(c.Storage) {
+		// nothing is persisted, so the only version is the one from the put
+		if startingVersion == (models.Version{}) {
+			return models.CheckResponse{}, nil
+		}
+		return models.CheckResponse{startingVersion}, nil
+	}
+
 	results, err := c.Storage.List()
 	if err != nil {
 		return nil, err
 	}
-	sort.Sort(byFileNameRegex(results))
+	if err = history.SortKeys(results); err != nil {
+		return nil, err
+	}
 
 	output := models.CheckResponse{}
 	for _, result := range results {
-		resultTimestamp, err := keyToTimestamp(result)
+		resultTimestamp, err := history.KeyToTimestamp(result)
 		if err != nil {
 			return nil, err
 		}
@@ -51,32 +53,3 @@ func (c Checker) Check(startingVersion models.Version) (models.CheckResponse, er
 
 	return output, nil
 }
-
-func keyToTimestamp(key string) (time.Time, error) {
-	iMatches := keyRegex.FindStringSubmatch(key)
-	if len(iMatches) == 0 {
-		return time.Time{}, fmt.Errorf("invalid filename '%s'", key)
-	}
-	iTime, err := time.Parse(timeFormat, iMatches[1])
-	if err != nil {
-		return time.Time{}, fmt.Errorf("invalid timestamp '%s'", key)
-	}
-
-	return iTime, nil
-}
-
-type byFileNameRegex []string
-
-func (a byFileNameRegex) Len() int      { return len(a) }
-func (a byFileNameRegex) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
-func (a byFileNameRegex) Less(i, j int) bool {
-	iTime, err := keyToTimestamp(a[i])
-	if err != nil {
-		panic(err)
-	}
-	jTime, err := keyToTimestamp(a[j])
-	if err != nil {
-		panic(err)
-	}
-	return iTime.Before(jTime)
-}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/ljfranklin/test-runner-resource/check"
 	"github.com/ljfranklin/test-runner-resource/models"
+	"github.com/ljfranklin/test-runner-resource/storage"
 	"github.com/ljfranklin/test-runner-resource/storage/storagefakes"
 	"github.com/ljfranklin/test-runner-resource/test/helpers"
 )
@@ -70,6 +71,30 @@ func TestCheckWithInputVersion(t *testing.T) {
 	})
 }
 
+func TestCheckWithDisabledStorage(t *testing.T) {
+	checker := check.Checker{
+		Storage: storage.NewDisabled(),
+	}
+
+	versions, err := checker.Check(models.Version{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	helpers.AssertEquals(t, versions, models.CheckResponse{})
+
+	versions, err = checker.Check(models.Version{
+		Key: "test-results-2018-01-02T15:04:05Z.xml",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	helpers.AssertEquals(t, versions, models.CheckResponse{
+		{
+			Key: "test-results-2018-01-02T15:04:05Z.xml",
+		},
+	})
+}
+
 func TestCheckErrorWithInvalidStartingVersion(t *testing.T) {
 	fakeStorage := &storagefakes.FakeStorage{}
 	fakeStorage.ListReturns([]string{}, nil)
@@ -0,0 +1,431 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ljfranklin/test-runner-resource/history"
+	"github.com/ljfranklin/test-runner-resource/in"
+	"github.com/ljfranklin/test-runner-resource/junit"
+	"github.com/ljfranklin/test-runner-resource/models"
+	"github.com/ljfranklin/test-runner-resource/out"
+	"github.com/ljfranklin/test-runner-resource/storage"
+	"github.com/ljfranklin/test-runner-resource/viewer"
+)
+
+const usage = `Usage: test-runner <command> [flags]
+
+Commands:
+  summarize  print summaries of results in storage or a local directory
+  list       list result keys in storage, newest first
+  fetch      download results from storage
+  upload     upload results files to storage as a single run
+
+Storage is configured with -config <file> or $TEST_RUNNER_CONFIG, which
+accept the resource's source as YAML or JSON, or with
+$TEST_RUNNER_STORAGE_TYPE and $TEST_RUNNER_STORAGE_CONFIG.
+
+Run 'test-runner <command> -h' for the flags of each command.
+`
+
+// CLI runs the summaries of the get step outside of Concourse.
+type CLI struct {
+	Stdout io.Writer
+	Stderr io.Writer
+	// defaults to time.Now
+	Now func() time.Time
+	// defaults to os.Getenv
+	Getenv func(string) string
+}
+
+func (c CLI) Run(args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(c.Stderr, usage)
+		return errors.New("missing command")
+	}
+
+	var err error
+	switch args[0] {
+	case "summarize":
+		err = c.summarize(args[1:])
+	case "list":
+		err = c.list(args[1:])
+	case "fetch":
+		err = c.fetch(args[1:])
+	case "upload":
+		err = c.upload(args[1:])
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(c.Stderr, usage)
+		return nil
+	default:
+		fmt.Fprint(c.Stderr, usage)
+		return fmt.Errorf("unrecognized command '%s'", args[0])
+	}
+	if err == flag.ErrHelp {
+		return nil
+	}
+	return err
+}
+
+func (c CLI) summarize(args []string) error {
+	flags := c.newFlagSet("summarize", "[flags]")
+	configPath := flags.String("config", "", "path to a YAML or JSON config file")
+	resultsDir := flags.String("results-dir", "", "summarize result files in a local directory instead of storage")
+	outputDir := flags.String("output-dir", "", "keep fetched results and reports in this directory")
+	reports := flags.Bool("reports", false, "write markdown, HTML, JSON and SVG reports next to the results")
+	summary := models.Summary{}
+	metadata := metadataFlag{}
+	flags.StringVar(&summary.Type, "type", "", "summary type, overrides the summaries in the config file")
+	flags.IntVar(&summary.Limit, "limit", 0, "number of runs to summarize, 0 for all")
+	flags.IntVar(&summary.Top, "top", 0, "number of tests to list in ranked summaries")
+	flags.StringVar(&summary.Filter.Job, "job", "", "only include runs of this job")
+	flags.Var(metadata, "metadata", "only include runs with metadata `name=value`, may be repeated")
+	flags.StringVar(&summary.Filter.Source, "source", "", "only include runs from this multi storage label")
+	flags.StringVar(&summary.OutputMatches, "output-matches", "", "regex matched against the output of failing tests, for pass-fail and frequent-failures")
+	flags.Float64Var(&summary.RegressionThreshold, "regression-threshold", 0, "percentage above the median duration which counts as a regression")
+	flags.Float64Var(&summary.RegressionMinSeconds, "regression-min-seconds", 0, "seconds above the median duration which a regression must also exceed, defaults to 0.5")
+	flags.BoolVar(&summary.BySource, "by-source", false, "report each multi storage label separately")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(flags.Args(), " "))
+	}
+	if len(metadata) > 0 {
+		summary.Filter.Metadata = metadata
+	}
+
+	var config Config
+	var err error
+	if *resultsDir == "" {
+		if config, err = LoadConfig(*configPath, c.getenv); err != nil {
+			return err
+		}
+	} else if *configPath != "" {
+		// only summaries are used when summarizing a local directory
+		if config, err = LoadConfig(*configPath, func(string) string { return "" }); err != nil {
+			return err
+		}
+	}
+
+	summaries := config.Summaries
+	if summary.Type != "" {
+		summaries = []models.Summary{summary}
+	}
+	if len(summaries) == 0 {
+		summaries = []models.Summary{{Type: "pass-fail"}}
+	}
+
+	if *resultsDir != "" {
+		runs, err := history.Load(*resultsDir)
+		if err != nil {
+			return err
+		}
+		if len(runs) == 0 {
+			return fmt.Errorf("found no test results in results dir '%s'", *resultsDir)
+		}
+		junitViewer := viewer.JunitNative{
+			OutputWriter: c.Stdout,
+		}
+		for _, summary := range summaries {
+			if err = junitViewer.PrintSummary(summary, runs); err != nil {
+				return err
+			}
+		}
+		if !*reports {
+			return nil
+		}
+		for _, reporter := range buildReporters(*resultsDir) {
+			if err = reporter.WriteReport(summaries, runs); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if *reports && *outputDir == "" {
+		return errors.New("-reports requires -output-dir or -results-dir")
+	}
+	store, err := newStorage(config)
+	if err != nil {
+		return err
+	}
+	defer storage.Close(store)
+
+	dir := *outputDir
+	if dir == "" {
+		if dir, err = ioutil.TempDir("", "test-runner"); err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+	} else if err = os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	keys, err := sortedKeys(store)
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return errors.New("found no test results in storage")
+	}
+
+	getter := in.Getter{
+		Storage: store,
+		JunitViewer: viewer.JunitNative{
+			OutputWriter: c.Stdout,
+		},
+	}
+	if *reports {
+		getter.Reporters = buildReporters(dir)
+	}
+	_, err = getter.Get(models.InRequest{
+		Version:   models.Version{Key: keys[0]},
+		Params:    models.InParams{Summaries: summaries},
+		OutputDir: dir,
+	})
+	return err
+}
+
+func (c CLI) list(args []string) error {
+	flags := c.newFlagSet("list", "[flags]")
+	configPath := flags.String("config", "", "path to a YAML or JSON config file")
+	limit := flags.Int("limit", 0, "maximum number of keys to list, 0 for all")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	store, err := c.openStorage(*configPath)
+	if err != nil {
+		return err
+	}
+	defer storage.Close(store)
+	keys, err := sortedKeys(store)
+	if err != nil {
+		return err
+	}
+	if *limit > 0 && len(keys) > *limit {
+		keys = keys[:*limit]
+	}
+	for _, key := range keys {
+		fmt.Fprintln(c.Stdout, key)
+	}
+	return nil
+}
+
+func (c CLI) fetch(args []string) error {
+	flags := c.newFlagSet("fetch", "[flags] [key...]")
+	configPath := flags.String("config", "", "path to a YAML or JSON config file")
+	outputDir := flags.String("output-dir", ".", "directory to download results into")
+	limit := flags.Int("limit", 0, "when no keys are given, fetch this many of the newest runs, 0 for all")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	store, err := c.openStorage(*configPath)
+	if err != nil {
+		return err
+	}
+	defer storage.Close(store)
+	keys := flags.Args()
+	if len(keys) == 0 {
+		if keys, err = sortedKeys(store); err != nil {
+			return err
+		}
+		if *limit > 0 && len(keys) > *limit {
+			keys = keys[:*limit]
+		}
+	}
+
+	for _, key := range keys {
+		if err = validateKey(key); err != nil {
+			return err
+		}
+		resultPath := filepath.Join(*outputDir, filepath.FromSlash(key))
+		if err = os.MkdirAll(filepath.Dir(resultPath), 0755); err != nil {
+			return err
+		}
+		contents := bytes.Buffer{}
+		if err = store.Get(key, &contents); err != nil {
+			return err
+		}
+		if err = ioutil.WriteFile(resultPath, contents.Bytes(), 0644); err != nil {
+			return err
+		}
+		fmt.Fprintln(c.Stdout, resultPath)
+	}
+	return nil
+}
+
+func (c CLI) upload(args []string) error {
+	flags := c.newFlagSet("upload", "[flags] file...")
+	configPath := flags.String("config", "", "path to a YAML or JSON config file")
+	timestamp := flags.String("timestamp", "", "RFC3339 time of the run, defaults to now")
+	source := flags.String("source", "", "multi storage label to upload to")
+	resultsType := flags.String("results-type", "junit", "format of the files, as in the put's results_type")
+	metadata := metadataFlag{}
+	flags.Var(metadata, "metadata", "record `name=value` on the run, may be repeated")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return errors.New("missing files to upload")
+	}
+
+	runTime := c.now()
+	if *timestamp != "" {
+		var err error
+		if runTime, err = time.Parse(time.RFC3339Nano, *timestamp); err != nil {
+			return fmt.Errorf("invalid timestamp '%s': %s", *timestamp, err)
+		}
+	}
+
+	documents := []junit.TestSuites{}
+	for _, path := range flags.Args() {
+		document, err := out.ParseResultsFile(path, *resultsType)
+		if err != nil {
+			return err
+		}
+		documents = append(documents, document)
+	}
+	results := junit.Merge(documents)
+	if len(metadata) > 0 {
+		results.SetMetadata(metadata)
+	}
+
+	store, err := c.openStorage(*configPath)
+	if err != nil {
+		return err
+	}
+	defer storage.Close(store)
+
+	key := history.TimestampToKey(runTime)
+	if *source != "" {
+		key = *source + "/" + key
+	}
+	contents := bytes.Buffer{}
+	if err = results.Write(&contents); err != nil {
+		return err
+	}
+	if err = store.Put(key, &contents); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(c.Stdout, key)
+	return nil
+}
+
+func (c CLI) openStorage(configPath string) (storage.Storage, error) {
+	config, err := LoadConfig(configPath, c.getenv)
+	if err != nil {
+		return nil, err
+	}
+	return newStorage(config)
+}
+
+func (c CLI) newFlagSet(command string, argsUsage string) *flag.FlagSet {
+	flags := flag.NewFlagSet(command, flag.ContinueOnError)
+	flags.SetOutput(c.Stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(c.Stderr, "Usage: test-runner %s %s\n\n", command, argsUsage)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+func (c CLI) now() time.Time {
+	if c.Now == nil {
+		return time.Now()
+	}
+	return c.Now()
+}
+
+func (c CLI) getenv(name string) string {
+	if c.Getenv == nil {
+		return os.Getenv(name)
+	}
+	return c.Getenv(name)
+}
+
+func newStorage(config Config) (storage.Storage, error) {
+	if config.StorageType == "" {
+		return nil, fmt.Errorf("storage_type must be set in the config file or $%s", storageTypeEnv)
+	}
+	store, err := storage.New(config.StorageType, config.StorageConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize storage: %s", err)
+	}
+	if storage.IsDisabled(store) {
+		return nil, errors.New("storage is disabled, nothing to read from or write to")
+	}
+	return store, nil
+}
+
+// validateKey rejects keys which could be written outside of the
+// output dir. Only the "<label>/" prefix of multi storage keys may
+// contain a separator.
+func validateKey(key string) error {
+	parts := strings.Split(key, "/")
+	valid := len(parts) <= 2 && !strings.Contains(key, `\`) && !strings.Contains(key, "..")
+	for _, part := range parts {
+		if part == "" || part == "." {
+			valid = false
+		}
+	}
+	if !valid {
+		return fmt.Errorf("invalid key '%s'; expected a key such as 'test-results-<timestamp>.xml' or '<label>/test-results-<timestamp>.xml'", key)
+	}
+	return nil
+}
+
+// sortedKeys lists the keys in s, newest first.
+func sortedKeys(s storage.Storage) ([]string, error) {
+	keys, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	if err = history.SortKeys(keys); err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(keys)-1; i < j; i, j = i+1, j-1 {
+		keys[i], keys[j] = keys[j], keys[i]
+	}
+	return keys, nil
+}
+
+func buildReporters(dir string) []viewer.Reporter {
+	return []viewer.Reporter{
+		viewer.MarkdownReport{ResultsDir: dir},
+		viewer.HTMLDashboard{ResultsDir: dir},
+		viewer.JSONReport{ResultsDir: dir},
+		viewer.SVGCharts{ResultsDir: dir},
+	}
+}
+
+// metadataFlag collects repeated `-metadata name=value` flags.
+type metadataFlag map[string]string
+
+func (m metadataFlag) String() string {
+	pairs := []string{}
+	for name, value := range m {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", name, value))
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (m metadataFlag) Set(value string) error {
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return fmt.Errorf("expected name=value but got '%s'", value)
+	}
+	m[parts[0]] = parts[1]
+	return nil
+}
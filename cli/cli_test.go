@@ -0,0 +1,218 @@
+package cli_test
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ljfranklin/test-runner-resource/cli"
+	"github.com/ljfranklin/test-runner-resource/test/helpers"
+)
+
+func fixturePath(fixture string) string {
+	return filepath.Join("..", "fixtures", "junit", fixture)
+}
+
+func copyFixture(t *testing.T, fixture string, destination string) {
+	t.Helper()
+
+	contents, err := ioutil.ReadFile(fixturePath(fixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.MkdirAll(filepath.Dir(destination), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(destination, contents, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newCLI(storageDir string) (cli.CLI, *bytes.Buffer) {
+	stdout := &bytes.Buffer{}
+	return cli.CLI{
+		Stdout: stdout,
+		Stderr: ioutil.Discard,
+		Now: func() time.Time {
+			return time.Date(2018, 1, 4, 15, 4, 5, 0, time.UTC)
+		},
+		Getenv: envFrom(map[string]string{
+			"TEST_RUNNER_STORAGE_TYPE":   "filesystem",
+			"TEST_RUNNER_STORAGE_CONFIG": fmt.Sprintf(`{"path": %q}`, storageDir),
+		}),
+	}, stdout
+}
+
+func TestListAndFetch(t *testing.T) {
+	storageDir, err := ioutil.TempDir("", "cli-storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(storageDir)
+
+	copyFixture(t, "failures.xml", filepath.Join(storageDir, "test-results-2018-01-01T15:04:05Z.xml"))
+	copyFixture(t, "success.xml", filepath.Join(storageDir, "test-results-2018-01-03T15:04:05Z.xml"))
+	copyFixture(t, "success.xml", filepath.Join(storageDir, "test-results-2018-01-02T15:04:05Z.xml"))
+
+	runner, stdout := newCLI(storageDir)
+	if err = runner.Run([]string{"list", "-limit", "2"}); err != nil {
+		t.Fatal(err)
+	}
+	helpers.AssertEquals(t, stdout.String(), "test-results-2018-01-03T15:04:05Z.xml\ntest-results-2018-01-02T15:04:05Z.xml\n")
+
+	outputDir, err := ioutil.TempDir("", "cli-output")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(outputDir)
+
+	stdout.Reset()
+	if err = runner.Run([]string{"fetch", "-output-dir", outputDir, "test-results-2018-01-01T15:04:05Z.xml"}); err != nil {
+		t.Fatal(err)
+	}
+	fetchedPath := filepath.Join(outputDir, "test-results-2018-01-01T15:04:05Z.xml")
+	helpers.AssertEquals(t, stdout.String(), fetchedPath+"\n")
+	if _, err = os.Stat(fetchedPath); err != nil {
+		t.Fatalf("expected '%s' to exist: %s", fetchedPath, err)
+	}
+
+	for _, key := range []string{"../test-results-2018-01-01T15:04:05Z.xml", "/etc/passwd", "a/b/test-results.xml", `..\test-results.xml`} {
+		err = runner.Run([]string{"fetch", "-output-dir", outputDir, key})
+		if err == nil {
+			t.Fatalf("expected err for key '%s' but none occurred", key)
+		}
+		if !strings.Contains(err.Error(), "invalid key") {
+			t.Fatalf("expected err to contain 'invalid key', but it did not: %s", err)
+		}
+	}
+}
+
+func TestUploadTAPResults(t *testing.T) {
+	storageDir, err := ioutil.TempDir("", "cli-storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(storageDir)
+
+	runner, stdout := newCLI(storageDir)
+	err = runner.Run([]string{"upload", "-results-type", "tap", filepath.Join("..", "fixtures", "tap", "bats.tap")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	helpers.AssertEquals(t, stdout.String(), "test-results-2018-01-04T15:04:05Z.xml\n")
+
+	stdout.Reset()
+	if err = runner.Run([]string{"summarize", "-type", "pass-fail"}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(stdout.String(), "0 of 1 runs passed") {
+		t.Fatalf("expected output to contain '0 of 1 runs passed' but it did not: %s", stdout.String())
+	}
+}
+
+func TestUploadThenSummarize(t *testing.T) {
+	storageDir, err := ioutil.TempDir("", "cli-storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(storageDir)
+
+	runner, stdout := newCLI(storageDir)
+	err = runner.Run([]string{"upload", "-timestamp", "2018-01-03T15:04:05Z", "-metadata", "iaas=azure", fixturePath("failures.xml")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	helpers.AssertEquals(t, stdout.String(), "test-results-2018-01-03T15:04:05Z.xml\n")
+
+	stdout.Reset()
+	if err = runner.Run([]string{"upload", fixturePath("success.xml")}); err != nil {
+		t.Fatal(err)
+	}
+	helpers.AssertEquals(t, stdout.String(), "test-results-2018-01-04T15:04:05Z.xml\n")
+
+	stdout.Reset()
+	if err = runner.Run([]string{"summarize", "-type", "pass-fail", "-metadata", "iaas=azure"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"Summary of last 1 runs (pass-fail) filtered by iaas=azure", "2018-01-03", "0 of 1 runs passed"} {
+		if !strings.Contains(stdout.String(), expected) {
+			t.Fatalf("expected output to contain '%s' but it did not: %s", expected, stdout.String())
+		}
+	}
+}
+
+func TestSummarizeLocalDirectory(t *testing.T) {
+	resultsDir, err := ioutil.TempDir("", "cli-results")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(resultsDir)
+
+	copyFixture(t, "failures.xml", filepath.Join(resultsDir, "test-results-2018-01-01T15:04:05Z.xml"))
+	copyFixture(t, "success.xml", filepath.Join(resultsDir, "test-results-2018-01-02T15:04:05Z.xml"))
+
+	configPath := filepath.Join(resultsDir, "config.yml")
+	err = ioutil.WriteFile(configPath, []byte("summaries:\n- type: flaky\n- type: pass-fail\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := bytes.Buffer{}
+	runner := cli.CLI{
+		Stdout: &stdout,
+		Stderr: ioutil.Discard,
+		Getenv: envFrom(nil),
+	}
+	if err = runner.Run([]string{"summarize", "-config", configPath, "-results-dir", resultsDir, "-reports"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{"(flaky)", "1 of 2 runs passed"} {
+		if !strings.Contains(stdout.String(), expected) {
+			t.Fatalf("expected output to contain '%s' but it did not: %s", expected, stdout.String())
+		}
+	}
+	for _, filename := range []string{"summary.md", "summary.json", "index.html", "pass-rate.svg"} {
+		if _, err := os.Stat(filepath.Join(resultsDir, filename)); err != nil {
+			t.Fatalf("expected '%s' to exist: %s", filename, err)
+		}
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	runner := cli.CLI{
+		Stdout: ioutil.Discard,
+		Stderr: ioutil.Discard,
+		Getenv: envFrom(nil),
+	}
+
+	for _, testCase := range []struct {
+		args     []string
+		expected string
+	}{
+		{args: []string{}, expected: "missing command"},
+		{args: []string{"some-command"}, expected: "some-command"},
+		{args: []string{"list"}, expected: "storage_type must be set"},
+		{args: []string{"summarize", "-reports"}, expected: "-reports requires"},
+		{args: []string{"upload"}, expected: "missing files"},
+		{args: []string{"upload", "-timestamp", "yesterday", fixturePath("success.xml")}, expected: "yesterday"},
+		{args: []string{"summarize", "-metadata", "no-value"}, expected: "name=value"},
+		{args: []string{"summarize", "-results-dir", "some-fake-dir"}, expected: "some-fake-dir"},
+		{args: []string{"upload", "-results-type", "xunit", fixturePath("success.xml")}, expected: "'gotest-json', 'junit', 'tap'"},
+	} {
+		err := runner.Run(testCase.args)
+		if err == nil {
+			t.Fatalf("expected err for args %v but none occurred", testCase.args)
+		}
+		if !strings.Contains(err.Error(), testCase.expected) {
+			t.Fatalf("expected err to contain '%s', but it did not: %s", testCase.expected, err)
+		}
+	}
+
+	helpers.AssertEquals(t, runner.Run([]string{"list", "-h"}), nil)
+}
@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/ljfranklin/test-runner-resource/models"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	configEnv        = "TEST_RUNNER_CONFIG"
+	storageTypeEnv   = "TEST_RUNNER_STORAGE_TYPE"
+	storageConfigEnv = "TEST_RUNNER_STORAGE_CONFIG"
+)
+
+// Config mirrors the resource's source and get params so pipeline
+// configuration can be reused as is, e.g.:
+//
+//	storage_type: s3
+//	storage_config:
+//	  bucket: my-bucket
+//	summaries:
+//	- type: flaky
+//	  limit: 50
+type Config struct {
+	models.Source
+	Summaries []models.Summary `json:"summaries"`
+}
+
+// LoadConfig reads the YAML or JSON file at path, falling back to
+// $TEST_RUNNER_CONFIG. $TEST_RUNNER_STORAGE_TYPE and
+// $TEST_RUNNER_STORAGE_CONFIG take precedence over the file.
+func LoadConfig(path string, getenv func(string) string) (Config, error) {
+	config := Config{}
+
+	if path == "" {
+		path = getenv(configEnv)
+	}
+	if path != "" {
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			return Config{}, fmt.Errorf("failed to read config file: %s", err)
+		}
+		if err = unmarshalYAML(contents, &config); err != nil {
+			return Config{}, fmt.Errorf("failed to parse config file '%s': %s", path, err)
+		}
+	}
+
+	if storageType := getenv(storageTypeEnv); storageType != "" {
+		config.StorageType = storageType
+	}
+	if storageConfig := getenv(storageConfigEnv); storageConfig != "" {
+		config.StorageConfig = nil
+		if err := unmarshalYAML([]byte(storageConfig), &config.StorageConfig); err != nil {
+			return Config{}, fmt.Errorf("failed to parse $%s: %s", storageConfigEnv, err)
+		}
+	}
+
+	return config, nil
+}
+
+// unmarshalYAML decodes YAML, a superset of JSON, through the json
+// struct tags used by models so values have the same types as they
+// would in a Concourse request.
+func unmarshalYAML(contents []byte, v interface{}) error {
+	var raw interface{}
+	if err := yaml.Unmarshal(contents, &raw); err != nil {
+		return err
+	}
+	normalized, err := normalize(raw)
+	if err != nil {
+		return err
+	}
+	asJSON, err := json.Marshal(normalized)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(asJSON, v)
+}
+
+// normalize converts the map[interface{}]interface{} values produced
+// by yaml.v2 into map[string]interface{} so they can be marshalled as JSON.
+func normalize(value interface{}) (interface{}, error) {
+	switch typed := value.(type) {
+	case map[interface{}]interface{}:
+		result := map[string]interface{}{}
+		for key, nested := range typed {
+			name, ok := key.(string)
+			if !ok {
+				return nil, fmt.Errorf("expected string key but got '%v'", key)
+			}
+			normalized, err := normalize(nested)
+			if err != nil {
+				return nil, err
+			}
+			result[name] = normalized
+		}
+		return result, nil
+	case []interface{}:
+		result := make([]interface{}, len(typed))
+		for i, nested := range typed {
+			normalized, err := normalize(nested)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = normalized
+		}
+		return result, nil
+	default:
+		return value, nil
+	}
+}
@@ -0,0 +1,126 @@
+package cli_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ljfranklin/test-runner-resource/cli"
+	"github.com/ljfranklin/test-runner-resource/models"
+	"github.com/ljfranklin/test-runner-resource/test/helpers"
+)
+
+func envFrom(env map[string]string) func(string) string {
+	return func(name string) string {
+		return env[name]
+	}
+}
+
+func TestLoadConfigFromYAML(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "cli-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	configPath := filepath.Join(tmpDir, "config.yml")
+	err = ioutil.WriteFile(configPath, []byte(`
+storage_type: multi
+storage_config:
+  storages:
+  - label: team-a
+    storage_type: gcs
+    storage_config:
+      bucket: some-bucket
+      chunk_size: 262144
+summaries:
+- type: flaky
+  limit: 50
+  filter:
+    job: integration
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := cli.LoadConfig(configPath, envFrom(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	helpers.AssertEquals(t, config.StorageType, "multi")
+	helpers.AssertEquals(t, config.StorageConfig, map[string]interface{}{
+		"storages": []interface{}{
+			map[string]interface{}{
+				"label":        "team-a",
+				"storage_type": "gcs",
+				"storage_config": map[string]interface{}{
+					"bucket":     "some-bucket",
+					"chunk_size": float64(262144),
+				},
+			},
+		},
+	})
+	helpers.AssertEquals(t, config.Summaries, []models.Summary{
+		{
+			Type:   "flaky",
+			Limit:  50,
+			Filter: models.Filter{Job: "integration"},
+		},
+	})
+}
+
+func TestLoadConfigFromEnv(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "cli-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	configPath := filepath.Join(tmpDir, "config.json")
+	err = ioutil.WriteFile(configPath, []byte(`{
+  "storage_type": "s3",
+  "storage_config": {"bucket": "some-bucket"},
+  "summaries": [{"type": "durations"}]
+}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := cli.LoadConfig("", envFrom(map[string]string{
+		"TEST_RUNNER_CONFIG":         configPath,
+		"TEST_RUNNER_STORAGE_TYPE":   "filesystem",
+		"TEST_RUNNER_STORAGE_CONFIG": `{"path": "/some/path"}`,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	helpers.AssertEquals(t, config.StorageType, "filesystem")
+	helpers.AssertEquals(t, config.StorageConfig, map[string]interface{}{
+		"path": "/some/path",
+	})
+	helpers.AssertEquals(t, config.Summaries, []models.Summary{{Type: "durations"}})
+}
+
+func TestLoadConfigErrorOnInvalidFile(t *testing.T) {
+	_, err := cli.LoadConfig("/some/missing/config.yml", envFrom(nil))
+	if err == nil {
+		t.Fatal("expected err to occur but it did not")
+	}
+	if !strings.Contains(err.Error(), "/some/missing/config.yml") {
+		t.Fatalf("expected err to contain '/some/missing/config.yml', but it did not: %s", err)
+	}
+
+	_, err = cli.LoadConfig("", envFrom(map[string]string{
+		"TEST_RUNNER_STORAGE_CONFIG": "{{{",
+	}))
+	if err == nil {
+		t.Fatal("expected err to occur but it did not")
+	}
+	if !strings.Contains(err.Error(), "TEST_RUNNER_STORAGE_CONFIG") {
+		t.Fatalf("expected err to contain 'TEST_RUNNER_STORAGE_CONFIG', but it did not: %s", err)
+	}
+}
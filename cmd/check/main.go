@@ -17,16 +17,19 @@ func main() {
 		log.Fatalf("failed to decode input JSON: %s", err)
 	}
 
-	storage, err := storage.New(request.Source.StorageType, request.Source.StorageConfig)
+	store, err := storage.New(request.Source.StorageType, request.Source.StorageConfig)
 	if err != nil {
 		log.Fatalf("failed to initialize storage: %s", err)
 	}
 
 	checker := check.Checker{
-		Storage: storage,
+		Storage: store,
 	}
 
 	results, err := checker.Check(request.Version)
+	if closeErr := storage.Close(store); closeErr != nil {
+		log.Printf("failed to clean up storage: %s", closeErr)
+	}
 	if err != nil {
 		log.Fatalf("failed to check for new versions: %s", err)
 	}
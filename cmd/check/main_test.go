@@ -199,6 +199,69 @@ func TestCheckCmdWithInitialVersion(t *testing.T) {
 	})
 }
 
+func TestCheckCmdWithFilesystemStorage(t *testing.T) {
+	t.Parallel()
+
+	storageDir, err := ioutil.TempDir("", "check")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(storageDir)
+
+	for _, key := range []string{
+		"test-results-2018-01-02T15:04:05Z.xml",
+		"test-results-2018-01-01T15:04:05Z.xml",
+		"test-results-2018-01-03T15:04:05Z.xml",
+	} {
+		copyFixture(t, "junit/success.xml", filepath.Join(storageDir, "results", key))
+	}
+
+	checkRequest := models.CheckRequest{
+		Source: models.Source{
+			StorageType: "filesystem",
+			StorageConfig: map[string]interface{}{
+				"path":        storageDir,
+				"path_prefix": "results",
+			},
+		},
+		Version: models.Version{
+			Key: "test-results-2018-01-02T15:04:05Z.xml",
+		},
+	}
+
+	checkJSON, err := json.Marshal(checkRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := bytes.Buffer{}
+	stderr := bytes.Buffer{}
+	cmd := exec.Command(mainPath)
+	cmd.Stdin = bytes.NewReader(checkJSON)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err = cmd.Run()
+	if err != nil {
+		t.Fatalf("failed to run check: %s, %s, %s", err, stdout.String(), stderr.String())
+	}
+
+	var checkOutput models.CheckResponse
+	err = json.Unmarshal(stdout.Bytes(), &checkOutput)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	helpers.AssertEquals(t, checkOutput, models.CheckResponse{
+		{
+			Key: "test-results-2018-01-02T15:04:05Z.xml",
+		},
+		{
+			Key: "test-results-2018-01-03T15:04:05Z.xml",
+		},
+	})
+}
+
 func TestCheckCmdErrorOnInvalidJSON(t *testing.T) {
 	t.Parallel()
 
@@ -350,6 +413,21 @@ func buildStorageConfig(t *testing.T) map[string]string {
 	return s3Config
 }
 
+func copyFixture(t *testing.T, fixture string, destination string) {
+	t.Helper()
+
+	contents, err := ioutil.ReadFile(fixturePath(fixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.MkdirAll(filepath.Dir(destination), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(destination, contents, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func fixturePath(fixture string) string {
 	return filepath.Join("..", "..", "fixtures", fixture)
 }
@@ -20,22 +20,38 @@ func main() {
 
 	request.OutputDir = os.Args[1]
 
-	storage, err := storage.New(request.Source.StorageType, request.Source.StorageConfig)
+	store, err := storage.New(request.Source.StorageType, request.Source.StorageConfig)
 	if err != nil {
 		log.Fatalf("failed to initialize storage: %s", err)
 	}
 
-	viewer := viewer.JunitCLI{
+	junitViewer := viewer.JunitNative{
 		OutputWriter: os.Stderr,
-		ResultsDir:   request.OutputDir,
 	}
 
 	getter := in.Getter{
-		Storage:     storage,
-		JunitViewer: viewer,
+		Storage:     store,
+		JunitViewer: junitViewer,
+		Reporters: []viewer.Reporter{
+			viewer.MarkdownReport{
+				ResultsDir: request.OutputDir,
+			},
+			viewer.HTMLDashboard{
+				ResultsDir: request.OutputDir,
+			},
+			viewer.JSONReport{
+				ResultsDir: request.OutputDir,
+			},
+			viewer.SVGCharts{
+				ResultsDir: request.OutputDir,
+			},
+		},
 	}
 
 	results, err := getter.Get(request)
+	if closeErr := storage.Close(store); closeErr != nil {
+		log.Printf("failed to clean up storage: %s", closeErr)
+	}
 	if err != nil {
 		log.Fatalf("failed to get requested version: %s", err)
 	}
@@ -125,6 +125,13 @@ func TestInCmd(t *testing.T) {
 		},
 		Metadata: map[string]string{
 			"test_suite_count": "2",
+			"latest_tests":     "30",
+			"latest_failures":  "0",
+			"latest_errors":    "0",
+			"latest_skipped":   "0",
+			"latest_duration":  "9.837s",
+			"pass_rate":        "100.0%",
+			"flaky_test_count": "0",
 		},
 	})
 
@@ -143,6 +150,96 @@ func TestInCmd(t *testing.T) {
 	}
 }
 
+func TestInCmdWithFilesystemStorage(t *testing.T) {
+	t.Parallel()
+
+	storageDir, err := ioutil.TempDir("", "in-storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(storageDir)
+
+	tmpDir, err := ioutil.TempDir("", "in")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	copyFixture(t, "junit/failures.xml", filepath.Join(storageDir, "test-results-2018-01-01T15:04:05Z.xml"))
+	copyFixture(t, "junit/success.xml", filepath.Join(storageDir, "test-results-2018-01-02T15:04:05Z.xml"))
+	copyFixture(t, "junit/success.xml", filepath.Join(storageDir, "test-results-2018-01-03T15:04:05Z.xml"))
+
+	inRequest := models.InRequest{
+		Version: models.Version{
+			Key: "test-results-2018-01-02T15:04:05Z.xml",
+		},
+		Params: models.InParams{
+			Summaries: []models.Summary{
+				{
+					Type:  "pass-fail",
+					Limit: 100,
+				},
+			},
+		},
+		Source: models.Source{
+			StorageType: "filesystem",
+			StorageConfig: map[string]interface{}{
+				"path": storageDir,
+			},
+		},
+	}
+
+	inJSON, err := json.Marshal(inRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := bytes.Buffer{}
+	stderr := bytes.Buffer{}
+	cmd := exec.Command(mainPath, tmpDir)
+	cmd.Stdin = bytes.NewReader(inJSON)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err = cmd.Run()
+	if err != nil {
+		t.Fatalf("failed to run in: %s, %s, %s", err, stdout.String(), stderr.String())
+	}
+
+	var inOutput models.InResponse
+	err = json.Unmarshal(stdout.Bytes(), &inOutput)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	helpers.AssertEquals(t, inOutput.Version, models.Version{
+		Key: "test-results-2018-01-02T15:04:05Z.xml",
+	})
+	helpers.AssertEquals(t, inOutput.Metadata["test_suite_count"], "2")
+	helpers.AssertEquals(t, inOutput.Metadata["latest_failures"], "0")
+	helpers.AssertEquals(t, inOutput.Metadata["flaky_test_count"], "0")
+
+	if !strings.Contains(stderr.String(), "Summary of last 2 runs") {
+		t.Fatalf("expected output to contain 'Summary of last 2 runs' but it did not: %s", stderr.String())
+	}
+
+	for _, filename := range []string{
+		"test-results-2018-01-01T15:04:05Z.xml",
+		"test-results-2018-01-02T15:04:05Z.xml",
+		"summary.md",
+		"summary.json",
+		"index.html",
+		"pass-rate.svg",
+	} {
+		if _, err := os.Stat(filepath.Join(tmpDir, filename)); os.IsNotExist(err) {
+			t.Fatalf("expected '%s' to exist but it does not", filename)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "test-results-2018-01-03T15:04:05Z.xml")); !os.IsNotExist(err) {
+		t.Fatalf("expected '%s' to NOT exist but it does", "test-results-2018-01-03T15:04:05Z.xml")
+	}
+}
+
 func TestInCmdErrorOnInvalidJSON(t *testing.T) {
 	t.Parallel()
 
@@ -327,6 +424,18 @@ func buildStorageConfig(t *testing.T) map[string]string {
 	return s3Config
 }
 
+func copyFixture(t *testing.T, fixture string, destination string) {
+	t.Helper()
+
+	contents, err := ioutil.ReadFile(fixturePath(fixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(destination, contents, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func fixturePath(fixture string) string {
 	return filepath.Join("..", "..", "fixtures", fixture)
 }
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/ljfranklin/test-runner-resource/models"
+	"github.com/ljfranklin/test-runner-resource/out"
+	"github.com/ljfranklin/test-runner-resource/runner"
+	"github.com/ljfranklin/test-runner-resource/storage"
+	"github.com/ljfranklin/test-runner-resource/viewer"
+)
+
+func main() {
+	var request models.OutRequest
+	err := json.NewDecoder(os.Stdin).Decode(&request)
+	if err != nil {
+		log.Fatalf("failed to decode input JSON: %s", err)
+	}
+
+	request.SourceDir = os.Args[1]
+
+	store, err := storage.New(request.Source.StorageType, request.Source.StorageConfig)
+	if err != nil {
+		log.Fatalf("failed to initialize storage: %s", err)
+	}
+
+	var testRunner runner.Runner
+	if request.Params.Runner == "local" {
+		testRunner = runner.Local{
+			OutputWriter: os.Stderr,
+		}
+	} else {
+		dockerRunner := runner.Docker{
+			OutputWriter: os.Stderr,
+		}
+		// the resource image ships scripts to run docker-in-docker next to the binary
+		assetsDir := filepath.Dir(os.Args[0])
+		startScript := filepath.Join(assetsDir, "start_docker_in_docker")
+		if _, err := os.Stat(startScript); err == nil {
+			dockerRunner.StartDaemonScript = startScript
+			dockerRunner.StopDaemonScript = filepath.Join(assetsDir, "stop_docker_in_docker")
+		}
+		testRunner = dockerRunner
+	}
+
+	putter := out.Putter{
+		Storage: store,
+		Runner:  testRunner,
+		JunitViewer: viewer.JunitNative{
+			OutputWriter: os.Stderr,
+		},
+	}
+
+	results, err := putter.Put(request)
+	// log.Fatalf skips deferred calls, so local state such as a
+	// git clone is removed before the result is checked
+	if closeErr := storage.Close(store); closeErr != nil {
+		log.Printf("failed to clean up storage: %s", closeErr)
+	}
+	if err != nil {
+		log.Fatalf("failed to put test results: %s", err)
+	}
+
+	err = json.NewEncoder(os.Stdout).Encode(results)
+	if err != nil {
+		log.Fatalf("failed to encode output JSON: %s", err)
+	}
+}
@@ -0,0 +1,188 @@
+package main_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ljfranklin/test-runner-resource/models"
+	"github.com/ljfranklin/test-runner-resource/test/helpers"
+)
+
+var (
+	mainPath string
+)
+
+func TestMain(m *testing.M) {
+	tmpDir, err := ioutil.TempDir("", "out")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	mainPath = buildMain(tmpDir)
+
+	os.Exit(m.Run())
+}
+
+func TestOutCmdErrorOnInvalidJSON(t *testing.T) {
+	t.Parallel()
+
+	tmpDir, err := ioutil.TempDir("", "out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	cmd := exec.Command(mainPath, tmpDir)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		defer stdin.Close()
+		stdin.Write([]byte("{{{"))
+	}()
+
+	combinedOutput, err := cmd.CombinedOutput()
+	if err == nil {
+		t.Fatalf("expected out to err but it did not: %s", string(combinedOutput))
+	}
+	if !strings.Contains(string(combinedOutput), "input JSON") {
+		t.Fatalf("expected error to contain 'input JSON' but it did not: %s", string(combinedOutput))
+	}
+}
+
+func TestOutCmdErrorOnInvalidStorageType(t *testing.T) {
+	t.Parallel()
+
+	tmpDir, err := ioutil.TempDir("", "out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	outRequest := models.OutRequest{
+		Source: models.Source{
+			StorageType:   "invalid-type",
+			StorageConfig: nil,
+		},
+	}
+
+	outJSON, err := json.Marshal(outRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(mainPath, tmpDir)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		defer stdin.Close()
+		stdin.Write(outJSON)
+	}()
+
+	combinedOutput, err := cmd.CombinedOutput()
+	if err == nil {
+		t.Fatalf("expected out to err but it did not: %s", string(combinedOutput))
+	}
+	if !strings.Contains(string(combinedOutput), "invalid-type") {
+		t.Fatalf("expected error to contain 'invalid-type' but it did not: %s", string(combinedOutput))
+	}
+}
+
+func TestOutCmdWithLocalRunner(t *testing.T) {
+	t.Parallel()
+
+	storageDir, err := ioutil.TempDir("", "out-storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(storageDir)
+
+	tmpDir, err := ioutil.TempDir("", "out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	fixture, err := filepath.Abs(filepath.Join("..", "..", "fixtures", "junit", "success.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.MkdirAll(filepath.Join(tmpDir, "ci-repo"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	outRequest := models.OutRequest{
+		Source: models.Source{
+			StorageType: "filesystem",
+			StorageConfig: map[string]interface{}{
+				"path": storageDir,
+			},
+		},
+		Params: models.OutParams{
+			Runner:  "local",
+			Command: `echo "running in $(basename $(pwd))"; cp "$FIXTURE" junit_1.xml`,
+			WorkDir: "ci-repo",
+			Env: map[string]string{
+				"FIXTURE": fixture,
+			},
+			Timeout: "1m",
+			ResultsConfig: models.ResultsConfig{
+				Path: "ci-repo/junit_*.xml",
+			},
+		},
+	}
+
+	outJSON, err := json.Marshal(outRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := bytes.Buffer{}
+	stderr := bytes.Buffer{}
+	cmd := exec.Command(mainPath, tmpDir)
+	cmd.Stdin = bytes.NewReader(outJSON)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err = cmd.Run(); err != nil {
+		t.Fatalf("failed to run out: %s, %s, %s", err, stdout.String(), stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "running in ci-repo") {
+		t.Fatalf("expected output to contain 'running in ci-repo' but it did not: %s", stderr.String())
+	}
+
+	var outResponse models.OutResponse
+	if err = json.Unmarshal(stdout.Bytes(), &outResponse); err != nil {
+		t.Fatal(err)
+	}
+	uploaded, err := ioutil.ReadDir(storageDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	helpers.AssertEquals(t, len(uploaded), 1)
+	helpers.AssertEquals(t, uploaded[0].Name(), outResponse.Version.Key)
+}
+
+func buildMain(tmpDir string) string {
+	mainPath := filepath.Join(tmpDir, "out")
+	cmd := exec.Command("go", "build", "-o", mainPath, "github.com/ljfranklin/test-runner-resource/cmd/out")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		panic(fmt.Sprintf("failed to build main.go: %s, %s", err, string(output)))
+	}
+
+	return mainPath
+}
@@ -0,0 +1,18 @@
+package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/ljfranklin/test-runner-resource/cli"
+)
+
+func main() {
+	c := cli.CLI{
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+	}
+	if err := c.Run(os.Args[1:]); err != nil {
+		log.Fatalf("test-runner: %s", err)
+	}
+}
@@ -0,0 +1,87 @@
+package main_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ljfranklin/test-runner-resource/test/helpers"
+)
+
+var (
+	mainPath string
+)
+
+func TestMain(m *testing.M) {
+	tmpDir, err := ioutil.TempDir("", "test-runner")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	mainPath = buildMain(tmpDir)
+
+	os.Exit(m.Run())
+}
+
+func TestTestRunnerCmd(t *testing.T) {
+	t.Parallel()
+
+	storageDir, err := ioutil.TempDir("", "test-runner-storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(storageDir)
+
+	configPath := filepath.Join(storageDir, "config.yml")
+	config := fmt.Sprintf("storage_type: filesystem\nstorage_config:\n  path: %s\n  path_prefix: results\n", storageDir)
+	if err = ioutil.WriteFile(configPath, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(mainPath, "upload", "-config", configPath, "-timestamp", "2018-01-02T15:04:05Z", fixturePath("junit/failures.xml"))
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("failed to run upload: %s, %s", err, string(output))
+	}
+
+	cmd = exec.Command(mainPath, "summarize", "-type", "frequent-failures")
+	cmd.Env = append(os.Environ(), "TEST_RUNNER_CONFIG="+configPath)
+	output, err = cmd.Output()
+	if err != nil {
+		t.Fatalf("failed to run summarize: %s, %s", err, string(output))
+	}
+	if !strings.Contains(string(output), "most frequent failures over last 1 runs") {
+		t.Fatalf("expected output to contain 'most frequent failures over last 1 runs' but it did not: %s", string(output))
+	}
+}
+
+func TestTestRunnerCmdErrorOnMissingCommand(t *testing.T) {
+	t.Parallel()
+
+	output, err := exec.Command(mainPath).CombinedOutput()
+	if err == nil {
+		t.Fatalf("expected test-runner to err but it did not: %s", string(output))
+	}
+	helpers.AssertEquals(t, strings.Contains(string(output), "Usage: test-runner"), true)
+	helpers.AssertEquals(t, strings.Contains(string(output), "missing command"), true)
+}
+
+func buildMain(tmpDir string) string {
+	mainPath := filepath.Join(tmpDir, "test-runner")
+	cmd := exec.Command("go", "build", "-o", mainPath, "github.com/ljfranklin/test-runner-resource/cmd/test-runner")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		panic(fmt.Sprintf("failed to build main.go: %s, %s", err, string(output)))
+	}
+
+	return mainPath
+}
+
+func fixturePath(fixture string) string {
+	return filepath.Join("..", "..", "fixtures", fixture)
+}
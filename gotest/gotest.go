@@ -0,0 +1,341 @@
+// Package gotest converts the event stream written by `go test -json`,
+// see `go doc test2json`, into the JUnit model stored by the resource.
+package gotest
+
+import (
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ljfranklin/test-runner-resource/junit"
+)
+
+var (
+	// lines which test2json also reports as actions
+	framingRegex = regexp.MustCompile(`^\s*(=== (RUN|PAUSE|CONT|NAME)|--- (PASS|FAIL|SKIP|BENCH):)`)
+	// e.g. "ok  \texample.com/calc\t0.012s" or "FAIL\texample.com/calc [build failed]"
+	packageResultRegex = regexp.MustCompile(`^(PASS|FAIL|ok\s+\S+.*|FAIL\s+\S+.*|\?\s+\S+.*)$`)
+)
+
+// event is a single line of `go test -json` output.
+type event struct {
+	Time    time.Time `json:"Time"`
+	Action  string    `json:"Action"`
+	Package string    `json:"Package"`
+	Test    string    `json:"Test"`
+	Elapsed float64   `json:"Elapsed"`
+	Output  string    `json:"Output"`
+	// set on build-output events and on packages which failed to build, Go 1.24+
+	ImportPath  string `json:"ImportPath"`
+	FailedBuild string `json:"FailedBuild"`
+}
+
+type testState struct {
+	name    string
+	output  []string
+	elapsed float64
+	// pass, fail or skip, empty if the test never finished
+	action string
+}
+
+type packageState struct {
+	name      string
+	timestamp string
+	tests     map[string]*testState
+	// tests in the order they started
+	order       []*testState
+	output      []string
+	elapsed     float64
+	action      string
+	failedBuild string
+}
+
+type parser struct {
+	packages map[string]*packageState
+	order    []*packageState
+	// build-output events keyed by ImportPath
+	buildOutput map[string][]string
+	// lines which are not events, e.g. build errors which older
+	// versions of Go write to stderr
+	strayOutput []string
+}
+
+func ParseFile(path string) (junit.TestSuites, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return junit.TestSuites{}, err
+	}
+	defer f.Close()
+
+	suites, err := Parse(f)
+	if err != nil {
+		return junit.TestSuites{}, fmt.Errorf("failed to parse '%s': %s", path, err)
+	}
+	return suites, nil
+}
+
+// Parse creates a suite for each package. Subtests are recorded as
+// test cases named after their parent, e.g. "TestDivide/by_zero".
+func Parse(r io.Reader) (junit.TestSuites, error) {
+	p := &parser{
+		packages:    map[string]*packageState{},
+		buildOutput: map[string][]string{},
+	}
+
+	foundEvents := false
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		var e event
+		if !strings.HasPrefix(strings.TrimSpace(line), "{") || json.Unmarshal([]byte(line), &e) != nil || e.Action == "" {
+			p.strayOutput = append(p.strayOutput, line)
+			continue
+		}
+		foundEvents = true
+		p.handle(e)
+	}
+	if err := scanner.Err(); err != nil {
+		return junit.TestSuites{}, fmt.Errorf("unable to read go test output: %s", err)
+	}
+	if !foundEvents {
+		return junit.TestSuites{}, fmt.Errorf("found no go test events")
+	}
+
+	documents := []junit.TestSuites{}
+	for _, pkg := range p.order {
+		// e.g. "?   \texample.com/util\t[no test files]"
+		if pkg.action == "skip" && len(pkg.order) == 0 {
+			continue
+		}
+		documents = append(documents, junit.TestSuites{
+			TestSuites: []junit.TestSuite{p.buildSuite(pkg)},
+		})
+	}
+
+	suites := junit.Merge(documents)
+	suites.XMLName = xml.Name{Local: "testsuites"}
+	return suites, nil
+}
+
+func (p *parser) handle(e event) {
+	switch e.Action {
+	case "build-output":
+		p.buildOutput[e.ImportPath] = append(p.buildOutput[e.ImportPath], strings.TrimSuffix(e.Output, "\n"))
+		return
+	case "build-fail":
+		return
+	}
+	if e.Package == "" {
+		return
+	}
+
+	pkg, ok := p.packages[e.Package]
+	if !ok {
+		pkg = &packageState{
+			name:  e.Package,
+			tests: map[string]*testState{},
+		}
+		p.packages[e.Package] = pkg
+		p.order = append(p.order, pkg)
+	}
+	if pkg.timestamp == "" && !e.Time.IsZero() {
+		pkg.timestamp = e.Time.UTC().Format(time.RFC3339)
+	}
+
+	if e.Test == "" {
+		switch e.Action {
+		case "output":
+			pkg.output = append(pkg.output, strings.TrimSuffix(e.Output, "\n"))
+		case "pass", "fail", "skip":
+			pkg.action = e.Action
+			pkg.elapsed = e.Elapsed
+			pkg.failedBuild = e.FailedBuild
+		}
+		return
+	}
+
+	// parallel tests interleave their events, so output is
+	// attributed by name rather than by position in the stream
+	test, ok := pkg.tests[e.Test]
+	if !ok {
+		test = &testState{name: e.Test}
+		pkg.tests[e.Test] = test
+		pkg.order = append(pkg.order, test)
+	}
+	switch e.Action {
+	case "output":
+		if !framingRegex.MatchString(e.Output) {
+			test.output = append(test.output, strings.TrimSuffix(e.Output, "\n"))
+		}
+	case "pass", "fail", "skip":
+		test.action = e.Action
+		test.elapsed = e.Elapsed
+	}
+}
+
+func (p *parser) buildSuite(pkg *packageState) junit.TestSuite {
+	className := path.Base(pkg.name)
+	packageOutput := []string{}
+	for _, line := range pkg.output {
+		if !packageResultRegex.MatchString(line) {
+			packageOutput = append(packageOutput, line)
+		}
+	}
+
+	suite := junit.TestSuite{
+		Name:      pkg.name,
+		Timestamp: pkg.timestamp,
+		Time:      pkg.elapsed,
+	}
+	failed := false
+	for _, test := range pkg.order {
+		output := dedent(test.output)
+		testCase := junit.TestCase{
+			Name:      test.name,
+			ClassName: className,
+			Time:      test.elapsed,
+		}
+
+		panicLine := findPanic(test.output)
+		switch {
+		case test.action == "":
+			// e.g. the package panicked or timed out while the test was running
+			if panicLine == "" {
+				panicLine = findPanic(packageOutput)
+			}
+			if panicLine == "" {
+				panicLine = "test did not complete"
+			}
+			testCase.Error = &junit.Error{Message: panicLine, Contents: output}
+		case test.action == "fail" && panicLine != "":
+			testCase.Error = &junit.Error{Message: panicLine, Contents: output}
+		case test.action == "fail":
+			testCase.Failure = &junit.Failure{Message: "Failed", Contents: output}
+		case test.action == "skip":
+			testCase.Skipped = &junit.Skipped{Contents: output}
+		case output != "":
+			testCase.SystemOut = &junit.SystemOut{Contents: output}
+		}
+		if test.action != "pass" && test.action != "skip" {
+			failed = true
+		}
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+
+	switch {
+	case pkg.failedBuild != "" || containsAny(pkg.output, "[build failed]", "[setup failed]"):
+		suite.TestCases = append(suite.TestCases, junit.TestCase{
+			Name:      "build",
+			ClassName: className,
+			Error: &junit.Error{
+				Message:  "build failed",
+				Contents: strings.Join(p.buildOutputFor(pkg), "\n"),
+			},
+		})
+	case pkg.action == "fail" && !failed:
+		// e.g. TestMain exited non-zero or an init func panicked
+		message := findPanic(packageOutput)
+		if message == "" {
+			message = "package failed"
+		}
+		suite.TestCases = append(suite.TestCases, junit.TestCase{
+			Name:      "package",
+			ClassName: className,
+			Error: &junit.Error{
+				Message:  message,
+				Contents: strings.Join(packageOutput, "\n"),
+			},
+		})
+	}
+
+	if len(packageOutput) > 0 {
+		suite.SystemOut = &junit.SystemOut{Contents: strings.Join(packageOutput, "\n")}
+	}
+	for _, testCase := range suite.TestCases {
+		suite.Tests++
+		switch testCase.Status() {
+		case junit.StatusFailed:
+			suite.Failures++
+		case junit.StatusError:
+			suite.Errors++
+		case junit.StatusSkipped:
+			suite.Skipped++
+		}
+	}
+	return suite
+}
+
+// buildOutputFor finds the compiler output for a package, which
+// begins with a header such as "# example.com/calc [example.com/calc.test]".
+func (p *parser) buildOutputFor(pkg *packageState) []string {
+	if output, ok := p.buildOutput[pkg.failedBuild]; ok {
+		return output
+	}
+
+	output := []string{}
+	inSection := false
+	for _, line := range p.strayOutput {
+		if strings.HasPrefix(line, "# ") {
+			fields := strings.Fields(line)
+			inSection = len(fields) > 1 && fields[1] == pkg.name
+		}
+		if inSection {
+			output = append(output, line)
+		}
+	}
+	if len(output) == 0 {
+		return p.strayOutput
+	}
+	return output
+}
+
+func findPanic(lines []string) string {
+	for _, line := range lines {
+		if strings.HasPrefix(line, "panic: ") {
+			return line
+		}
+	}
+	return ""
+}
+
+func containsAny(lines []string, substrings ...string) bool {
+	for _, line := range lines {
+		for _, substring := range substrings {
+			if strings.Contains(line, substring) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// dedent removes the indentation which `go test` adds to t.Log output.
+func dedent(lines []string) string {
+	indent := -1
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		lineIndent := len(line) - len(strings.TrimLeft(line, " "))
+		if indent == -1 || lineIndent < indent {
+			indent = lineIndent
+		}
+	}
+
+	dedented := []string{}
+	for _, line := range lines {
+		if len(line) >= indent && indent > 0 {
+			line = line[indent:]
+		}
+		dedented = append(dedented, line)
+	}
+	return strings.TrimRight(strings.Join(dedented, "\n"), "\n")
+}
@@ -0,0 +1,141 @@
+package gotest_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ljfranklin/test-runner-resource/gotest"
+	"github.com/ljfranklin/test-runner-resource/junit"
+	"github.com/ljfranklin/test-runner-resource/test/helpers"
+)
+
+func fixturePath(fixture string) string {
+	return filepath.Join("..", "fixtures", "gotest", fixture)
+}
+
+func statuses(suites junit.TestSuites) map[string]string {
+	result := map[string]string{}
+	suites.Walk(func(suite junit.TestSuite, testCase junit.TestCase) {
+		result[suite.Name+": "+testCase.Name] = testCase.Status()
+	})
+	return result
+}
+
+func TestParseInterleavedSubtests(t *testing.T) {
+	suites, err := gotest.ParseFile(fixturePath("parallel.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// packages without test files are omitted
+	helpers.AssertEquals(t, len(suites.TestSuites), 1)
+	helpers.AssertEquals(t, suites.Time, 0.516)
+
+	suite := suites.TestSuites[0]
+	helpers.AssertEquals(t, suite.Name, "example.com/calc")
+	helpers.AssertEquals(t, suite.Timestamp, "2018-01-02T15:04:05Z")
+	helpers.AssertEquals(t, suite.Tests, 5)
+	helpers.AssertEquals(t, suite.SystemOut, (*junit.SystemOut)(nil))
+
+	names := []string{}
+	for _, testCase := range suite.TestCases {
+		names = append(names, testCase.Name)
+	}
+	helpers.AssertEquals(t, names, []string{"TestAdd", "TestDivide", "TestDivide/by_zero", "TestDivide/by_one", "TestNetwork"})
+
+	helpers.AssertEquals(t, suite.TestCases[0], junit.TestCase{
+		Name:      "TestAdd",
+		ClassName: "calc",
+		Time:      0.01,
+		SystemOut: &junit.SystemOut{Contents: "calc_test.go:10: adding 1 and 2"},
+	})
+	helpers.AssertEquals(t, suite.TestCases[1].Failure, &junit.Failure{Message: "Failed"})
+	helpers.AssertEquals(t, suite.TestCases[2].Time, 0.2)
+	helpers.AssertEquals(t, suite.TestCases[2].Failure, &junit.Failure{
+		Message:  "Failed",
+		Contents: "divide_test.go:30: expected an error but got nil\n    result: +Inf",
+	})
+	helpers.AssertEquals(t, suite.TestCases[3].SystemOut, &junit.SystemOut{
+		Contents: "divide_test.go:20: dividing 4 by 1\ndivide_test.go:22: got 4",
+	})
+	helpers.AssertEquals(t, suite.TestCases[4].Skipped, &junit.Skipped{Contents: "calc_test.go:40: requires network access"})
+}
+
+func TestParseRecordsPanicsAsErrors(t *testing.T) {
+	suites, err := gotest.ParseFile(fixturePath("panic.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	helpers.AssertEquals(t, statuses(suites), map[string]string{
+		"example.com/parser: TestParse": junit.StatusError,
+		"example.com/slow: TestSlow":    junit.StatusError,
+	})
+
+	parse := suites.TestSuites[0].TestCases[0]
+	helpers.AssertEquals(t, parse.Error.Message, "panic: runtime error: index out of range [3] with length 3 [recovered]")
+	if !strings.Contains(parse.Error.Contents, "goroutine 7 [running]:") {
+		t.Fatalf("expected error to include the stack trace, but it did not: %s", parse.Error.Contents)
+	}
+
+	// the timeout is reported by the package rather than the running test
+	helpers.AssertEquals(t, suites.TestSuites[1].TestCases[0].Error.Message, "panic: test timed out after 1s")
+	helpers.AssertEquals(t, suites.TestSuites[1].Time, 1.005)
+}
+
+func TestParseRecordsBuildFailures(t *testing.T) {
+	suites, err := gotest.ParseFile(fixturePath("build-failure.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	helpers.AssertEquals(t, statuses(suites), map[string]string{
+		"example.com/broken: build": junit.StatusError,
+		"example.com/legacy: build": junit.StatusError,
+		"example.com/ok: TestOK":    junit.StatusPassed,
+	})
+	helpers.AssertEquals(t, suites.TestSuites[0].TestCases[0].Error, &junit.Error{
+		Message:  "build failed",
+		Contents: "# example.com/broken [example.com/broken.test]\n./broken_test.go:8:2: undefined: missingFunc",
+	})
+	helpers.AssertEquals(t, suites.TestSuites[1].TestCases[0].Error, &junit.Error{
+		Message:  "build failed",
+		Contents: "# example.com/legacy\n./legacy.go:5:1: syntax error: non-declaration statement outside function body",
+	})
+}
+
+func TestParseRecordsPackageFailures(t *testing.T) {
+	stream := `{"Action":"run","Package":"example.com/db","Test":"TestQuery"}
+{"Action":"pass","Package":"example.com/db","Test":"TestQuery","Elapsed":0.1}
+{"Action":"output","Package":"example.com/db","Output":"TestMain: unable to connect to database\n"}
+{"Action":"output","Package":"example.com/db","Output":"FAIL\texample.com/db\t0.2s\n"}
+{"Action":"fail","Package":"example.com/db","Elapsed":0.2}
+`
+	suites, err := gotest.Parse(strings.NewReader(stream))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	helpers.AssertEquals(t, statuses(suites), map[string]string{
+		"example.com/db: TestQuery": junit.StatusPassed,
+		"example.com/db: package":   junit.StatusError,
+	})
+	helpers.AssertEquals(t, suites.TestSuites[0].TestCases[1].Error, &junit.Error{
+		Message:  "package failed",
+		Contents: "TestMain: unable to connect to database",
+	})
+}
+
+func TestParseErrorOnMissingEvents(t *testing.T) {
+	_, err := gotest.ParseFile(filepath.Join("..", "fixtures", "junit", "success.xml"))
+	if err == nil {
+		t.Fatal("expected err to occur but it did not")
+	}
+	if !strings.Contains(err.Error(), "success.xml") {
+		t.Fatalf("expected err to contain 'success.xml', but it did not: %s", err)
+	}
+	if !strings.Contains(err.Error(), "found no go test events") {
+		t.Fatalf("expected err to contain 'found no go test events', but it did not: %s", err)
+	}
+}
@@ -0,0 +1,119 @@
+package history
+
+import (
+	"math"
+	"sort"
+
+	"github.com/ljfranklin/test-runner-resource/junit"
+)
+
+type DurationStat struct {
+	// Test.Name is empty for suite durations
+	Test    TestID
+	Samples int
+	Latest  float64
+	Median  float64
+	P95     float64
+	// median of every sample except the latest
+	PreviousMedian float64
+	// percentage change of Latest over PreviousMedian
+	Change float64
+}
+
+// TestDurations returns duration stats for every test, slowest median first.
+// Skipped executions are ignored.
+func TestDurations(runs []Run) []DurationStat {
+	samples := map[TestID][]float64{}
+	for _, run := range chronological(runs) {
+		run.Suites.Walk(func(suite junit.TestSuite, testCase junit.TestCase) {
+			if testCase.Status() == junit.StatusSkipped {
+				return
+			}
+			id := NewTestID(suite, testCase)
+			samples[id] = append(samples[id], testCase.Time)
+		})
+	}
+	return durationStats(samples)
+}
+
+// SuiteDurations returns duration stats for every top-level suite,
+// slowest median first.
+func SuiteDurations(runs []Run) []DurationStat {
+	samples := map[TestID][]float64{}
+	for _, run := range chronological(runs) {
+		for _, suite := range run.Suites.TestSuites {
+			id := TestID{Suite: suite.Name}
+			samples[id] = append(samples[id], suite.Time)
+		}
+	}
+	return durationStats(samples)
+}
+
+// Regressions returns stats whose latest duration exceeds the
+// previous median by more than threshold percent and by at least
+// minSeconds, which avoids noise from fast tests, largest change first.
+func Regressions(stats []DurationStat, threshold float64, minSeconds float64) []DurationStat {
+	regressions := []DurationStat{}
+	for _, stat := range stats {
+		if stat.Samples < 2 || stat.Latest-stat.PreviousMedian < minSeconds {
+			continue
+		}
+		if stat.Change > threshold {
+			regressions = append(regressions, stat)
+		}
+	}
+	sort.SliceStable(regressions, func(i, j int) bool {
+		return regressions[i].Change > regressions[j].Change
+	})
+	return regressions
+}
+
+func durationStats(samplesByTest map[TestID][]float64) []DurationStat {
+	stats := []DurationStat{}
+	for id, samples := range samplesByTest {
+		stat := DurationStat{
+			Test:    id,
+			Samples: len(samples),
+			Latest:  samples[len(samples)-1],
+			Median:  percentile(samples, 50),
+			P95:     percentile(samples, 95),
+		}
+		if len(samples) > 1 {
+			stat.PreviousMedian = percentile(samples[:len(samples)-1], 50)
+			if stat.PreviousMedian > 0 {
+				stat.Change = (stat.Latest - stat.PreviousMedian) / stat.PreviousMedian * 100
+			}
+		}
+		stats = append(stats, stat)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Median != stats[j].Median {
+			return stats[i].Median > stats[j].Median
+		}
+		return stats[i].Test.String() < stats[j].Test.String()
+	})
+	return stats
+}
+
+// percentile uses linear interpolation between the closest ranks.
+func percentile(samples []float64, p float64) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	sorted := make([]float64, len(samples))
+	copy(sorted, samples)
+	sort.Float64s(sorted)
+
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	fraction := rank - float64(lower)
+	return sorted[lower] + (sorted[upper]-sorted[lower])*fraction
+}
+
+func chronological(runs []Run) []Run {
+	sorted := make([]Run, len(runs))
+	copy(sorted, runs)
+	sort.Stable(byTimestamp(sorted))
+	return sorted
+}
@@ -0,0 +1,87 @@
+package history_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/ljfranklin/test-runner-resource/history"
+	"github.com/ljfranklin/test-runner-resource/test/helpers"
+)
+
+func durationRuns(t *testing.T) []history.Run {
+	return []history.Run{
+		parseRun(t, "test-results-2018-01-04T15:04:05Z.xml", `
+<testsuites>
+	<testsuite name="api" time="25">
+		<testcase name="TestSlow" time="20"></testcase>
+		<testcase name="TestFast" time="0.2"></testcase>
+	</testsuite>
+	<testsuite name="cli" time="1"></testsuite>
+</testsuites>`),
+		parseRun(t, "test-results-2018-01-03T15:04:05Z.xml", `
+<testsuites>
+	<testsuite name="api" time="14">
+		<testcase name="TestSlow" time="12"></testcase>
+		<testcase name="TestFast" time="0.1"></testcase>
+	</testsuite>
+	<testsuite name="cli" time="1"></testsuite>
+</testsuites>`),
+		parseRun(t, "test-results-2018-01-02T15:04:05Z.xml", `
+<testsuites>
+	<testsuite name="api" time="12">
+		<testcase name="TestSlow" time="10"></testcase>
+		<testcase name="TestFast" time="0.1"></testcase>
+	</testsuite>
+	<testsuite name="cli" time="1"></testsuite>
+</testsuites>`),
+		parseRun(t, "test-results-2018-01-01T15:04:05Z.xml", `
+<testsuites>
+	<testsuite name="api" time="10">
+		<testcase name="TestSlow" time="8"></testcase>
+		<testcase name="TestFast" time="0.1"></testcase>
+		<testcase name="TestSkipped" time="0"><skipped></skipped></testcase>
+	</testsuite>
+	<testsuite name="cli" time="1"></testsuite>
+</testsuites>`),
+	}
+}
+
+func TestTestDurations(t *testing.T) {
+	stats := history.TestDurations(durationRuns(t))
+
+	helpers.AssertEquals(t, len(stats), 2)
+	helpers.AssertEquals(t, stats[0].Test, history.TestID{Suite: "api", Name: "TestSlow"})
+	helpers.AssertEquals(t, stats[0].Samples, 4)
+	helpers.AssertEquals(t, stats[0].Latest, 20.0)
+	helpers.AssertEquals(t, stats[0].Median, 11.0)
+	helpers.AssertEquals(t, math.Round(stats[0].P95*100)/100, 18.8)
+	helpers.AssertEquals(t, stats[0].PreviousMedian, 10.0)
+	helpers.AssertEquals(t, stats[0].Change, 100.0)
+	helpers.AssertEquals(t, stats[1].Test, history.TestID{Suite: "api", Name: "TestFast"})
+}
+
+func TestSuiteDurations(t *testing.T) {
+	stats := history.SuiteDurations(durationRuns(t))
+
+	helpers.AssertEquals(t, len(stats), 2)
+	helpers.AssertEquals(t, stats[0].Test, history.TestID{Suite: "api"})
+	helpers.AssertEquals(t, stats[0].Test.String(), "api")
+	helpers.AssertEquals(t, stats[0].Median, 13.0)
+	helpers.AssertEquals(t, stats[1].Test, history.TestID{Suite: "cli"})
+	helpers.AssertEquals(t, stats[1].Change, 0.0)
+}
+
+func TestRegressions(t *testing.T) {
+	stats := history.TestDurations(durationRuns(t))
+
+	// TestFast doubled as well but is below the minimum absolute change
+	regressions := history.Regressions(stats, 50, 0.5)
+	helpers.AssertEquals(t, len(regressions), 1)
+	helpers.AssertEquals(t, regressions[0].Test.Name, "TestSlow")
+
+	regressions = history.Regressions(stats, 50, 0.1)
+	helpers.AssertEquals(t, len(regressions), 2)
+	helpers.AssertEquals(t, regressions[1].Test.Name, "TestFast")
+
+	helpers.AssertEquals(t, len(history.Regressions(stats, 150, 0.5)), 0)
+}
@@ -0,0 +1,101 @@
+package history
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ljfranklin/test-runner-resource/junit"
+)
+
+const maxMessageLength = 120
+
+type FailureStat struct {
+	Test TestID
+	// number of runs in which the test was executed
+	Runs        int
+	Failures    int
+	FailureRate float64
+	FirstKey    string
+	LatestKey   string
+	// truncated output from the most recent failure
+	Message string
+}
+
+// FrequentFailures ranks tests by failure count and then failure rate.
+// runs must be ordered from newest to oldest. If pattern is non-nil
+// only failures whose output matches are counted.
+func FrequentFailures(runs []Run, pattern *regexp.Regexp) []FailureStat {
+	statsByTest := map[TestID]*FailureStat{}
+	for _, run := range runs {
+		run.Suites.Walk(func(suite junit.TestSuite, testCase junit.TestCase) {
+			status := testCase.Status()
+			if status == junit.StatusSkipped {
+				return
+			}
+
+			id := NewTestID(suite, testCase)
+			stat, ok := statsByTest[id]
+			if !ok {
+				stat = &FailureStat{Test: id}
+				statsByTest[id] = stat
+			}
+			stat.Runs++
+
+			if status != junit.StatusFailed && status != junit.StatusError {
+				return
+			}
+			if pattern != nil && !MatchesOutput(suite, testCase, pattern) {
+				return
+			}
+			stat.Failures++
+			// runs are newest first, so the first failure seen is the latest
+			if stat.LatestKey == "" {
+				stat.LatestKey = run.Key
+				stat.Message = FailureMessage(testCase)
+			}
+			stat.FirstKey = run.Key
+		})
+	}
+
+	stats := []FailureStat{}
+	for _, stat := range statsByTest {
+		if stat.Failures == 0 {
+			continue
+		}
+		stat.FailureRate = float64(stat.Failures) / float64(stat.Runs)
+		stats = append(stats, *stat)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Failures != stats[j].Failures {
+			return stats[i].Failures > stats[j].Failures
+		}
+		if stats[i].FailureRate != stats[j].FailureRate {
+			return stats[i].FailureRate > stats[j].FailureRate
+		}
+		return stats[i].Test.String() < stats[j].Test.String()
+	})
+
+	return stats
+}
+
+// FailureMessage returns a single line summary of why testCase failed.
+func FailureMessage(testCase junit.TestCase) string {
+	var message, contents string
+	switch {
+	case testCase.Error != nil:
+		message, contents = testCase.Error.Message, testCase.Error.Contents
+	case testCase.Failure != nil:
+		message, contents = testCase.Failure.Message, testCase.Failure.Contents
+	}
+
+	// the body is usually more descriptive than the message, e.g. "Failed"
+	text := strings.Join(strings.Fields(contents), " ")
+	if text == "" {
+		text = strings.Join(strings.Fields(message), " ")
+	}
+	if runes := []rune(text); len(runes) > maxMessageLength {
+		text = string(runes[:maxMessageLength-3]) + "..."
+	}
+	return text
+}
@@ -0,0 +1,114 @@
+package history_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/ljfranklin/test-runner-resource/history"
+	"github.com/ljfranklin/test-runner-resource/junit"
+	"github.com/ljfranklin/test-runner-resource/test/helpers"
+)
+
+func parseRun(t *testing.T, key string, contents string) history.Run {
+	t.Helper()
+
+	suites, err := junit.Parse(strings.NewReader(contents))
+	if err != nil {
+		t.Fatal(err)
+	}
+	timestamp, err := history.KeyToTimestamp(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return history.Run{
+		Key:       key,
+		Timestamp: timestamp,
+		Suites:    suites,
+	}
+}
+
+func TestFrequentFailures(t *testing.T) {
+	// newest first
+	runs := []history.Run{
+		parseRun(t, "test-results-2018-01-03T15:04:05Z.xml", `
+<testsuite name="api">
+	<testcase name="TestCreate"><failure message="Failed">502 Bad Gateway</failure></testcase>
+	<testcase name="TestDelete"><failure message="Failed">expected 204, got 500</failure></testcase>
+	<testcase name="TestList"></testcase>
+</testsuite>`),
+		parseRun(t, "test-results-2018-01-02T15:04:05Z.xml", `
+<testsuite name="api">
+	<testcase name="TestCreate"><error message="timeout"></error></testcase>
+	<testcase name="TestDelete"><skipped></skipped></testcase>
+	<testcase name="TestList"></testcase>
+</testsuite>`),
+		parseRun(t, "test-results-2018-01-01T15:04:05Z.xml", `
+<testsuite name="api">
+	<testcase name="TestCreate"><failure message="Failed">502 Bad Gateway</failure></testcase>
+	<testcase name="TestDelete"></testcase>
+	<testcase name="TestList"></testcase>
+</testsuite>`),
+	}
+
+	stats := history.FrequentFailures(runs, nil)
+
+	helpers.AssertEquals(t, stats, []history.FailureStat{
+		{
+			Test:        history.TestID{Suite: "api", Name: "TestCreate"},
+			Runs:        3,
+			Failures:    3,
+			FailureRate: 1,
+			FirstKey:    "test-results-2018-01-01T15:04:05Z.xml",
+			LatestKey:   "test-results-2018-01-03T15:04:05Z.xml",
+			Message:     "502 Bad Gateway",
+		},
+		{
+			Test:        history.TestID{Suite: "api", Name: "TestDelete"},
+			Runs:        2,
+			Failures:    1,
+			FailureRate: 0.5,
+			FirstKey:    "test-results-2018-01-03T15:04:05Z.xml",
+			LatestKey:   "test-results-2018-01-03T15:04:05Z.xml",
+			Message:     "expected 204, got 500",
+		},
+	})
+}
+
+func TestFrequentFailuresWithOutputPattern(t *testing.T) {
+	runs := []history.Run{
+		parseRun(t, "test-results-2018-01-02T15:04:05Z.xml", `
+<testsuite name="api">
+	<testcase name="TestCreate"><error message="timeout"></error></testcase>
+	<testcase name="TestDelete"><failure>expected 204, got 500</failure></testcase>
+</testsuite>`),
+		parseRun(t, "test-results-2018-01-01T15:04:05Z.xml", `
+<testsuite name="api">
+	<testcase name="TestCreate"><failure message="Failed">502 Bad Gateway</failure></testcase>
+	<testcase name="TestDelete"></testcase>
+</testsuite>`),
+	}
+
+	stats := history.FrequentFailures(runs, regexp.MustCompile(`502`))
+
+	helpers.AssertEquals(t, len(stats), 1)
+	helpers.AssertEquals(t, stats[0].Test.Name, "TestCreate")
+	helpers.AssertEquals(t, stats[0].Failures, 1)
+	helpers.AssertEquals(t, stats[0].Runs, 2)
+	helpers.AssertEquals(t, stats[0].LatestKey, "test-results-2018-01-01T15:04:05Z.xml")
+}
+
+func TestFailureMessage(t *testing.T) {
+	helpers.AssertEquals(t, history.FailureMessage(junit.TestCase{
+		Failure: &junit.Failure{Message: "Failed", Contents: "\n  some_test.go:12:\n\texpected true  "},
+	}), "some_test.go:12: expected true")
+	helpers.AssertEquals(t, history.FailureMessage(junit.TestCase{
+		Error: &junit.Error{Message: "panic: nil pointer"},
+	}), "panic: nil pointer")
+
+	message := history.FailureMessage(junit.TestCase{
+		Failure: &junit.Failure{Contents: strings.Repeat("x", 500)},
+	})
+	helpers.AssertEquals(t, len(message), 120)
+	helpers.AssertEquals(t, strings.HasSuffix(message, "..."), true)
+}
@@ -0,0 +1,45 @@
+package history
+
+import (
+	"github.com/ljfranklin/test-runner-resource/junit"
+	"github.com/ljfranklin/test-runner-resource/models"
+)
+
+func IsFiltered(filter models.Filter) bool {
+	return len(filter.Metadata) > 0 || filter.Job != "" || filter.Source != ""
+}
+
+// Matches reports whether the metadata recorded on suites
+// contains every key and value in filter.
+func Matches(suites junit.TestSuites, filter models.Filter) bool {
+	metadata := suites.Metadata()
+	for name, value := range filter.Metadata {
+		if actual, ok := metadata[name]; !ok || actual != value {
+			return false
+		}
+	}
+	if filter.Job != "" && metadata["build_job_name"] != filter.Job {
+		return false
+	}
+	return true
+}
+
+// MatchesSource reports whether key was fetched from the storage
+// label in filter, if any.
+func MatchesSource(key string, filter models.Filter) bool {
+	return filter.Source == "" || KeySource(key) == filter.Source
+}
+
+func Filter(runs []Run, filter models.Filter) []Run {
+	if !IsFiltered(filter) {
+		return runs
+	}
+
+	filtered := []Run{}
+	for _, run := range runs {
+		if MatchesSource(run.Key, filter) && Matches(run.Suites, filter) {
+			filtered = append(filtered, run)
+		}
+	}
+	return filtered
+}
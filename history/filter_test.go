@@ -0,0 +1,88 @@
+package history_test
+
+import (
+	"testing"
+
+	"github.com/ljfranklin/test-runner-resource/history"
+	"github.com/ljfranklin/test-runner-resource/junit"
+	"github.com/ljfranklin/test-runner-resource/models"
+	"github.com/ljfranklin/test-runner-resource/test/helpers"
+)
+
+func runWithMetadata(key string, metadata map[string]string) history.Run {
+	suites := junit.TestSuites{}
+	suites.SetMetadata(metadata)
+	return history.Run{
+		Key:    key,
+		Suites: suites,
+	}
+}
+
+func TestMatches(t *testing.T) {
+	suites := junit.TestSuites{}
+	suites.SetMetadata(map[string]string{
+		"iaas":           "azure",
+		"branch":         "main",
+		"build_job_name": "integration",
+	})
+
+	helpers.AssertEquals(t, history.Matches(suites, models.Filter{}), true)
+	helpers.AssertEquals(t, history.Matches(suites, models.Filter{
+		Metadata: map[string]string{"iaas": "azure"},
+	}), true)
+	helpers.AssertEquals(t, history.Matches(suites, models.Filter{
+		Metadata: map[string]string{"iaas": "azure", "branch": "main"},
+		Job:      "integration",
+	}), true)
+	helpers.AssertEquals(t, history.Matches(suites, models.Filter{
+		Metadata: map[string]string{"iaas": "gcp"},
+	}), false)
+	helpers.AssertEquals(t, history.Matches(suites, models.Filter{
+		Metadata: map[string]string{"missing": ""},
+	}), false)
+	helpers.AssertEquals(t, history.Matches(suites, models.Filter{
+		Job: "unit",
+	}), false)
+}
+
+func TestFilter(t *testing.T) {
+	runs := []history.Run{
+		runWithMetadata("test-results-2018-01-03T15:04:05Z.xml", map[string]string{"iaas": "azure"}),
+		runWithMetadata("test-results-2018-01-02T15:04:05Z.xml", map[string]string{"iaas": "gcp"}),
+		runWithMetadata("test-results-2018-01-01T15:04:05Z.xml", map[string]string{"iaas": "azure"}),
+	}
+
+	filtered := history.Filter(runs, models.Filter{
+		Metadata: map[string]string{"iaas": "azure"},
+	})
+	keys := []string{}
+	for _, run := range filtered {
+		keys = append(keys, run.Key)
+	}
+	helpers.AssertEquals(t, keys, []string{
+		"test-results-2018-01-03T15:04:05Z.xml",
+		"test-results-2018-01-01T15:04:05Z.xml",
+	})
+
+	helpers.AssertEquals(t, len(history.Filter(runs, models.Filter{})), 3)
+}
+
+func TestFilterBySource(t *testing.T) {
+	runs := []history.Run{
+		runWithMetadata("team-a/test-results-2018-01-03T15:04:05Z.xml", map[string]string{"iaas": "azure"}),
+		runWithMetadata("team-b/test-results-2018-01-02T15:04:05Z.xml", map[string]string{"iaas": "azure"}),
+		runWithMetadata("team-a/test-results-2018-01-01T15:04:05Z.xml", map[string]string{"iaas": "gcp"}),
+	}
+
+	filtered := history.Filter(runs, models.Filter{
+		Metadata: map[string]string{"iaas": "azure"},
+		Source:   "team-a",
+	})
+	keys := []string{}
+	for _, run := range filtered {
+		keys = append(keys, run.Key)
+	}
+	helpers.AssertEquals(t, keys, []string{
+		"team-a/test-results-2018-01-03T15:04:05Z.xml",
+	})
+}
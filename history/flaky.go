@@ -0,0 +1,82 @@
+package history
+
+import (
+	"sort"
+
+	"github.com/ljfranklin/test-runner-resource/junit"
+)
+
+// a single flip is a regression or a fix, a flaky test has to
+// change status and then change back
+const minFlakyFlips = 2
+
+type FlakyStat struct {
+	Test     TestID
+	Runs     int
+	Passes   int
+	Failures int
+	// number of times the test switched between passing and failing
+	Flips int
+	// Flips divided by the number of possible flips, from 0 to 1
+	Score     float64
+	LatestKey string
+}
+
+// Flaky returns the tests which flipped between passing and failing
+// at least twice within runs, most flaky first. Skipped executions
+// are ignored.
+func Flaky(runs []Run) []FlakyStat {
+	statsByTest := map[TestID]*FlakyStat{}
+	lastFailed := map[TestID]bool{}
+	for _, run := range chronological(runs) {
+		run.Suites.Walk(func(suite junit.TestSuite, testCase junit.TestCase) {
+			status := testCase.Status()
+			if status == junit.StatusSkipped {
+				return
+			}
+
+			id := NewTestID(suite, testCase)
+			stat, seen := statsByTest[id]
+			if !seen {
+				stat = &FlakyStat{Test: id}
+				statsByTest[id] = stat
+			}
+
+			failed := status == junit.StatusFailed || status == junit.StatusError
+			if failed {
+				stat.Failures++
+			} else {
+				stat.Passes++
+			}
+			if seen && failed != lastFailed[id] {
+				stat.Flips++
+			}
+			lastFailed[id] = failed
+			stat.Runs++
+			stat.LatestKey = run.Key
+		})
+	}
+
+	stats := []FlakyStat{}
+	for _, stat := range statsByTest {
+		if stat.Flips < minFlakyFlips {
+			continue
+		}
+		stat.Score = float64(stat.Flips) / float64(stat.Runs-1)
+		stats = append(stats, *stat)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Score != stats[j].Score {
+			return stats[i].Score > stats[j].Score
+		}
+		if stats[i].Flips != stats[j].Flips {
+			return stats[i].Flips > stats[j].Flips
+		}
+		if stats[i].Failures != stats[j].Failures {
+			return stats[i].Failures > stats[j].Failures
+		}
+		return stats[i].Test.String() < stats[j].Test.String()
+	})
+
+	return stats
+}
@@ -0,0 +1,69 @@
+package history_test
+
+import (
+	"testing"
+
+	"github.com/ljfranklin/test-runner-resource/history"
+	"github.com/ljfranklin/test-runner-resource/test/helpers"
+)
+
+func TestFlaky(t *testing.T) {
+	// deliberately out of order to ensure runs are sorted by timestamp
+	runs := []history.Run{
+		parseRun(t, "test-results-2018-01-02T15:04:05Z.xml", `
+<testsuite name="api">
+	<testcase name="TestFlipFlop"><failure>boom</failure></testcase>
+	<testcase name="TestBroken"><failure>boom</failure></testcase>
+	<testcase name="TestStable"></testcase>
+	<testcase name="TestOnceFlaky"></testcase>
+</testsuite>`),
+		parseRun(t, "test-results-2018-01-04T15:04:05Z.xml", `
+<testsuite name="api">
+	<testcase name="TestFlipFlop"><failure>boom</failure></testcase>
+	<testcase name="TestBroken"><failure>boom</failure></testcase>
+	<testcase name="TestStable"></testcase>
+	<testcase name="TestOnceFlaky"></testcase>
+</testsuite>`),
+		parseRun(t, "test-results-2018-01-01T15:04:05Z.xml", `
+<testsuite name="api">
+	<testcase name="TestFlipFlop"></testcase>
+	<testcase name="TestBroken"></testcase>
+	<testcase name="TestStable"></testcase>
+	<testcase name="TestOnceFlaky"></testcase>
+</testsuite>`),
+		parseRun(t, "test-results-2018-01-03T15:04:05Z.xml", `
+<testsuite name="api">
+	<testcase name="TestFlipFlop"></testcase>
+	<testcase name="TestBroken"><failure>boom</failure></testcase>
+	<testcase name="TestStable"><skipped></skipped></testcase>
+	<testcase name="TestOnceFlaky"><error>timeout</error></testcase>
+</testsuite>`),
+	}
+
+	stats := history.Flaky(runs)
+
+	// TestBroken only regressed once, which is not a flake
+	helpers.AssertEquals(t, stats, []history.FlakyStat{
+		{
+			Test:      history.TestID{Suite: "api", Name: "TestFlipFlop"},
+			Runs:      4,
+			Passes:    2,
+			Failures:  2,
+			Flips:     3,
+			Score:     1,
+			LatestKey: "test-results-2018-01-04T15:04:05Z.xml",
+		},
+		{
+			Test:      history.TestID{Suite: "api", Name: "TestOnceFlaky"},
+			Runs:      4,
+			Passes:    3,
+			Failures:  1,
+			Flips:     2,
+			Score:     2.0 / 3.0,
+			LatestKey: "test-results-2018-01-04T15:04:05Z.xml",
+		},
+	})
+
+	// input order is preserved
+	helpers.AssertEquals(t, runs[0].Key, "test-results-2018-01-02T15:04:05Z.xml")
+}
@@ -0,0 +1,149 @@
+package history
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/ljfranklin/test-runner-resource/junit"
+)
+
+var (
+	keyRegex = regexp.MustCompile(`/?test-results-(.+)\.xml$`)
+	// e.g. "2006-01-02T15:04:05Z", sub-second precision avoids
+	// key clashes between parallel runs
+	timeFormat = time.RFC3339Nano
+)
+
+type Run struct {
+	Key       string
+	Timestamp time.Time
+	Suites    junit.TestSuites
+	// label of the storage the run was fetched from, if aggregated
+	Source string
+}
+
+type TestID struct {
+	Suite     string
+	ClassName string
+	Name      string
+}
+
+func NewTestID(suite junit.TestSuite, testCase junit.TestCase) TestID {
+	return TestID{
+		Suite:     suite.Name,
+		ClassName: testCase.ClassName,
+		Name:      testCase.Name,
+	}
+}
+
+func (t TestID) String() string {
+	if t.Suite == "" {
+		return t.Name
+	}
+	if t.Name == "" {
+		return t.Suite
+	}
+	return fmt.Sprintf("%s: %s", t.Suite, t.Name)
+}
+
+func KeyToTimestamp(key string) (time.Time, error) {
+	iMatches := keyRegex.FindStringSubmatch(key)
+	if len(iMatches) == 0 {
+		return time.Time{}, fmt.Errorf("invalid filename '%s'", key)
+	}
+	iTime, err := time.Parse(timeFormat, iMatches[1])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timestamp '%s'", key)
+	}
+
+	return iTime, nil
+}
+
+// KeySource returns the storage label a key is namespaced under,
+// e.g. "team-a" for "team-a/test-results-<timestamp>.xml".
+func KeySource(key string) string {
+	if dir := path.Dir(key); dir != "." {
+		return dir
+	}
+	return ""
+}
+
+func TimestampToKey(timestamp time.Time) string {
+	return fmt.Sprintf("test-results-%s.xml", timestamp.UTC().Format(timeFormat))
+}
+
+// Load parses every result file in dir, including those namespaced
+// under a storage label, ordered from newest to oldest.
+func Load(dir string) ([]Run, error) {
+	xmlFiles := []string{}
+	for _, pattern := range []string{"test-results-*.xml", "*/test-results-*.xml"} {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return nil, fmt.Errorf("unable to glob for files: %s", err)
+		}
+		xmlFiles = append(xmlFiles, matches...)
+	}
+
+	runs := []Run{}
+	for _, xmlFile := range xmlFiles {
+		relPath, err := filepath.Rel(dir, xmlFile)
+		if err != nil {
+			return nil, err
+		}
+		key := filepath.ToSlash(relPath)
+		timestamp, err := KeyToTimestamp(key)
+		if err != nil {
+			return nil, err
+		}
+		suites, err := junit.ParseFile(xmlFile)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, Run{
+			Key:       key,
+			Timestamp: timestamp,
+			Suites:    suites,
+			Source:    KeySource(key),
+		})
+	}
+	sort.Sort(sort.Reverse(byTimestamp(runs)))
+
+	return runs, nil
+}
+
+// SortKeys orders keys from oldest to newest.
+func SortKeys(keys []string) error {
+	for _, key := range keys {
+		if _, err := KeyToTimestamp(key); err != nil {
+			return err
+		}
+	}
+	sort.Sort(byFileNameRegex(keys))
+	return nil
+}
+
+type byTimestamp []Run
+
+func (a byTimestamp) Len() int           { return len(a) }
+func (a byTimestamp) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a byTimestamp) Less(i, j int) bool { return a[i].Timestamp.Before(a[j].Timestamp) }
+
+type byFileNameRegex []string
+
+func (a byFileNameRegex) Len() int      { return len(a) }
+func (a byFileNameRegex) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a byFileNameRegex) Less(i, j int) bool {
+	iTime, err := KeyToTimestamp(a[i])
+	if err != nil {
+		panic(err)
+	}
+	jTime, err := KeyToTimestamp(a[j])
+	if err != nil {
+		panic(err)
+	}
+	return iTime.Before(jTime)
+}
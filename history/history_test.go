@@ -0,0 +1,145 @@
+package history_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ljfranklin/test-runner-resource/history"
+	"github.com/ljfranklin/test-runner-resource/test/helpers"
+)
+
+func fixturePath(fixture string) string {
+	return filepath.Join("..", "fixtures", "junit", fixture)
+}
+
+func TestKeyToTimestamp(t *testing.T) {
+	timestamp, err := history.KeyToTimestamp("some/prefix/test-results-2018-01-02T15:04:05Z.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	helpers.AssertEquals(t, timestamp.Equal(time.Date(2018, 1, 2, 15, 4, 5, 0, time.UTC)), true)
+}
+
+func TestKeyToTimestampErrorOnInvalidKey(t *testing.T) {
+	_, err := history.KeyToTimestamp("test-results-invalid-time.xml")
+	if err == nil {
+		t.Fatal("expected err to occur but it did not")
+	}
+	if !strings.Contains(err.Error(), "invalid-time") {
+		t.Fatalf("expected err to contain 'invalid-time', but it did not: %s", err)
+	}
+}
+
+func TestTimestampToKeyRoundTrips(t *testing.T) {
+	original := time.Date(2018, 1, 2, 15, 4, 5, 123000000, time.FixedZone("some-zone", 3600))
+
+	key := history.TimestampToKey(original)
+	helpers.AssertEquals(t, key, "test-results-2018-01-02T14:04:05.123Z.xml")
+
+	parsed, err := history.KeyToTimestamp(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	helpers.AssertEquals(t, parsed.Equal(original), true)
+}
+
+func TestSortKeys(t *testing.T) {
+	keys := []string{
+		"test-results-2018-01-02T15:04:05Z.xml",
+		"test-results-2018-01-02T15:04:05.5Z.xml",
+		"test-results-2018-01-01T15:04:05Z.xml",
+	}
+
+	err := history.SortKeys(keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	helpers.AssertEquals(t, keys, []string{
+		"test-results-2018-01-01T15:04:05Z.xml",
+		"test-results-2018-01-02T15:04:05Z.xml",
+		"test-results-2018-01-02T15:04:05.5Z.xml",
+	})
+}
+
+func TestSortKeysErrorOnInvalidKey(t *testing.T) {
+	err := history.SortKeys([]string{
+		"test-results-2018-01-02T15:04:05Z.xml",
+		"test-results-invalid-time.xml",
+	})
+	if err == nil {
+		t.Fatal("expected err to occur but it did not")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "history-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	fixtures := map[string]string{
+		"test-results-2018-03-14T14:22:46Z.xml": "failures.xml",
+		"test-results-2018-03-15T14:22:46Z.xml": "success.xml",
+	}
+	for key, fixture := range fixtures {
+		contents, err := ioutil.ReadFile(fixturePath(fixture))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = ioutil.WriteFile(filepath.Join(tmpDir, key), contents, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	runs, err := history.Load(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	helpers.AssertEquals(t, len(runs), 2)
+	helpers.AssertEquals(t, runs[0].Key, "test-results-2018-03-15T14:22:46Z.xml")
+	helpers.AssertEquals(t, runs[0].Suites.Totals().Failures, 0)
+	helpers.AssertEquals(t, runs[1].Key, "test-results-2018-03-14T14:22:46Z.xml")
+	helpers.AssertEquals(t, runs[1].Suites.Totals().Failures, 8)
+}
+
+func TestLoadNamespacedKeys(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "history-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	fixtures := map[string]string{
+		"team-a/test-results-2018-03-14T14:22:46Z.xml": "failures.xml",
+		"team-b/test-results-2018-03-15T14:22:46Z.xml": "success.xml",
+	}
+	for key, fixture := range fixtures {
+		contents, err := ioutil.ReadFile(fixturePath(fixture))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = os.MkdirAll(filepath.Join(tmpDir, filepath.Dir(key)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err = ioutil.WriteFile(filepath.Join(tmpDir, key), contents, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	runs, err := history.Load(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	helpers.AssertEquals(t, len(runs), 2)
+	helpers.AssertEquals(t, runs[0].Key, "team-b/test-results-2018-03-15T14:22:46Z.xml")
+	helpers.AssertEquals(t, runs[0].Source, "team-b")
+	helpers.AssertEquals(t, runs[1].Key, "team-a/test-results-2018-03-14T14:22:46Z.xml")
+	helpers.AssertEquals(t, runs[1].Source, "team-a")
+}
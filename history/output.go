@@ -0,0 +1,54 @@
+package history
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/ljfranklin/test-runner-resource/junit"
+)
+
+// MatchesOutput reports whether a failing test case has a failure,
+// error, system-out or system-err which matches pattern. The output
+// of the enclosing suite is only checked when the test case has no
+// output of its own, as producers such as go-junit-report only record
+// output there; otherwise one match in the shared suite output would
+// count every failure in the suite.
+func MatchesOutput(suite junit.TestSuite, testCase junit.TestCase, pattern *regexp.Regexp) bool {
+	outputs := []string{}
+	var contents string
+	switch testCase.Status() {
+	case junit.StatusFailed:
+		outputs = append(outputs, testCase.Failure.Message)
+		contents = testCase.Failure.Contents
+	case junit.StatusError:
+		outputs = append(outputs, testCase.Error.Message)
+		contents = testCase.Error.Contents
+	default:
+		return false
+	}
+
+	ownOutputs := []string{contents}
+	if testCase.SystemOut != nil {
+		ownOutputs = append(ownOutputs, testCase.SystemOut.Contents)
+	}
+	if testCase.SystemErr != nil {
+		ownOutputs = append(ownOutputs, testCase.SystemErr.Contents)
+	}
+	if strings.TrimSpace(strings.Join(ownOutputs, "")) != "" {
+		outputs = append(outputs, ownOutputs...)
+	} else {
+		if suite.SystemOut != nil {
+			outputs = append(outputs, suite.SystemOut.Contents)
+		}
+		if suite.SystemErr != nil {
+			outputs = append(outputs, suite.SystemErr.Contents)
+		}
+	}
+
+	for _, output := range outputs {
+		if pattern.MatchString(output) {
+			return true
+		}
+	}
+	return false
+}
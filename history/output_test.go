@@ -0,0 +1,55 @@
+package history_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/ljfranklin/test-runner-resource/history"
+	"github.com/ljfranklin/test-runner-resource/junit"
+	"github.com/ljfranklin/test-runner-resource/test/helpers"
+)
+
+func TestMatchesOutput(t *testing.T) {
+	pattern := regexp.MustCompile(`502 Bad Gateway`)
+
+	helpers.AssertEquals(t, history.MatchesOutput(junit.TestSuite{}, junit.TestCase{
+		Failure: &junit.Failure{Contents: "request failed: 502 Bad Gateway"},
+	}, pattern), true)
+	helpers.AssertEquals(t, history.MatchesOutput(junit.TestSuite{}, junit.TestCase{
+		Error: &junit.Error{Message: "502 Bad Gateway"},
+	}, pattern), true)
+	helpers.AssertEquals(t, history.MatchesOutput(junit.TestSuite{}, junit.TestCase{
+		Failure:   &junit.Failure{Contents: "request failed"},
+		SystemOut: &junit.SystemOut{Contents: "got 502 Bad Gateway"},
+	}, pattern), true)
+	helpers.AssertEquals(t, history.MatchesOutput(junit.TestSuite{}, junit.TestCase{
+		Failure:   &junit.Failure{Contents: "request failed"},
+		SystemErr: &junit.SystemErr{Contents: "got 502 Bad Gateway"},
+	}, pattern), true)
+	helpers.AssertEquals(t, history.MatchesOutput(junit.TestSuite{}, junit.TestCase{
+		Failure: &junit.Failure{Contents: "expected 3 instances"},
+	}, pattern), false)
+	helpers.AssertEquals(t, history.MatchesOutput(junit.TestSuite{}, junit.TestCase{
+		SystemOut: &junit.SystemOut{Contents: "got 502 Bad Gateway"},
+	}, pattern), false)
+
+	helpers.AssertEquals(t, history.MatchesOutput(junit.TestSuite{
+		SystemOut: &junit.SystemOut{Contents: "got 502 Bad Gateway"},
+	}, junit.TestCase{
+		Failure: &junit.Failure{Message: "Failed"},
+	}, pattern), true)
+	helpers.AssertEquals(t, history.MatchesOutput(junit.TestSuite{
+		SystemErr: &junit.SystemErr{Contents: "got 502 Bad Gateway"},
+	}, junit.TestCase{}, pattern), false)
+
+	// suite output is shared, so it is only used by test cases without output
+	suite := junit.TestSuite{
+		SystemOut: &junit.SystemOut{Contents: "TestUpload: got 502 Bad Gateway"},
+		TestCases: []junit.TestCase{
+			{Name: "TestUpload", Failure: &junit.Failure{Contents: "upload failed: 502 Bad Gateway"}},
+			{Name: "TestScale", Failure: &junit.Failure{Contents: "expected 3 instances"}},
+		},
+	}
+	helpers.AssertEquals(t, history.MatchesOutput(suite, suite.TestCases[0], pattern), true)
+	helpers.AssertEquals(t, history.MatchesOutput(suite, suite.TestCases[1], pattern), false)
+}
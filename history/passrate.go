@@ -0,0 +1,18 @@
+package history
+
+// PassRate is the percentage of executed tests which passed across
+// runs. Skipped tests were not executed so they are excluded. ok is
+// false if no tests were executed.
+func PassRate(runs []Run) (rate float64, ok bool) {
+	executed := 0
+	passed := 0
+	for _, run := range runs {
+		totals := run.Suites.Totals()
+		executed += totals.Tests - totals.Skipped
+		passed += totals.Passed()
+	}
+	if executed == 0 {
+		return 0, false
+	}
+	return float64(passed) * 100 / float64(executed), true
+}
@@ -0,0 +1,37 @@
+package history_test
+
+import (
+	"testing"
+
+	"github.com/ljfranklin/test-runner-resource/history"
+	"github.com/ljfranklin/test-runner-resource/test/helpers"
+)
+
+func TestPassRateExcludesSkipped(t *testing.T) {
+	runs := []history.Run{
+		parseRun(t, "test-results-2018-01-02T15:04:05Z.xml", `
+<testsuite name="api">
+	<testcase name="TestPasses"></testcase>
+	<testcase name="TestFails"><failure>boom</failure></testcase>
+	<testcase name="TestSkipped"><skipped></skipped></testcase>
+</testsuite>`),
+		parseRun(t, "test-results-2018-01-01T15:04:05Z.xml", `
+<testsuite name="api">
+	<testcase name="TestPasses"></testcase>
+	<testcase name="TestFails"></testcase>
+	<testcase name="TestSkipped"><skipped></skipped></testcase>
+</testsuite>`),
+	}
+
+	rate, ok := history.PassRate(runs)
+	helpers.AssertEquals(t, ok, true)
+	helpers.AssertEquals(t, rate, 75.0)
+
+	_, ok = history.PassRate([]history.Run{
+		parseRun(t, "test-results-2018-01-03T15:04:05Z.xml", `
+<testsuite name="api">
+	<testcase name="TestSkipped"><skipped></skipped></testcase>
+</testsuite>`),
+	})
+	helpers.AssertEquals(t, ok, false)
+}
@@ -1,44 +1,54 @@
 package in
 
 import (
-	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
-	"sort"
-	"time"
 
+	"github.com/ljfranklin/test-runner-resource/history"
 	"github.com/ljfranklin/test-runner-resource/models"
 	"github.com/ljfranklin/test-runner-resource/storage"
 	"github.com/ljfranklin/test-runner-resource/viewer"
 )
 
-var (
-	keyRegex = regexp.MustCompile(`/?test-results-(.+)\.xml$`)
-	// e.g. "2006-01-02T15:04:05Z"
-	timeFormat = time.RFC3339
-)
-
 type Getter struct {
 	Storage     storage.Storage
 	JunitViewer viewer.Junit
+	Reporters   []viewer.Reporter
 }
 
 func (g Getter) Get(request models.InRequest) (models.InResponse, error) {
-	startingTimestamp, err := keyToTimestamp(request.Version.Key)
+	startingTimestamp, err := history.KeyToTimestamp(request.Version.Key)
 	if err != nil {
 		return models.InResponse{}, err
 	}
 
+	if storage.IsDisabled(g.Storage) {
+		// nothing was persisted, so there is no run to summarize here;
+		// the put printed summaries of its own run instead
+		return models.InResponse{
+			Version: request.Version,
+			Metadata: map[string]string{
+				"test_suite_count": "0",
+				"storage":          "disabled",
+			},
+		}, nil
+	}
+
 	results, err := g.Storage.List()
 	if err != nil {
 		return models.InResponse{}, err
 	}
-	sort.Sort(sort.Reverse(byFileNameRegex(results)))
+	if err = history.SortKeys(results); err != nil {
+		return models.InResponse{}, err
+	}
+	// newest first
+	for i, j := 0, len(results)-1; i < j; i, j = i+1, j-1 {
+		results[i], results[j] = results[j], results[i]
+	}
 
 	keysToFetch := []string{}
 	for _, result := range results {
-		resultTimestamp, err := keyToTimestamp(result)
+		resultTimestamp, err := history.KeyToTimestamp(result)
 		if err != nil {
 			return models.InResponse{}, err
 		}
@@ -47,72 +57,105 @@ func (g Getter) Get(request models.InRequest) (models.InResponse, error) {
 		}
 	}
 
-	highestLimit := 0
-	for _, summary := range request.Params.Summaries {
-		if summary.Limit > highestLimit {
-			highestLimit = summary.Limit
-		}
-	}
-	if highestLimit > 0 && len(keysToFetch) > highestLimit {
-		keysToFetch = keysToFetch[:highestLimit]
-	}
-
 	// TODO: parallelize
+	summaries := request.Params.Summaries
+	// each result is parsed once and shared by the viewer, the
+	// reporters and the metadata
+	runs := []history.Run{}
+	matchCounts := make([]int, len(summaries))
 	for _, key := range keysToFetch {
-		f, err := os.Create(filepath.Join(request.OutputDir, key))
+		if enoughRunsFetched(summaries, len(runs), matchCounts) {
+			break
+		}
+
+		resultPath := filepath.Join(request.OutputDir, key)
+		if err = g.fetch(key, resultPath); err != nil {
+			return models.InResponse{}, err
+		}
+		run, err := loadFetchedRun(request.OutputDir, key)
 		if err != nil {
 			return models.InResponse{}, err
 		}
+		runs = append(runs, run)
+
+		for i, summary := range summaries {
+			if !history.IsFiltered(summary.Filter) || !history.MatchesSource(key, summary.Filter) {
+				continue
+			}
+			if history.Matches(run.Suites, summary.Filter) {
+				matchCounts[i]++
+			}
+		}
+	}
 
-		if err = g.Storage.Get(key, f); err != nil {
+	for _, summary := range request.Params.Summaries {
+		if err = g.JunitViewer.PrintSummary(summary, runs); err != nil {
 			return models.InResponse{}, err
 		}
+	}
 
-		if err = f.Close(); err != nil {
+	for _, reporter := range g.Reporters {
+		if err = reporter.WriteReport(request.Params.Summaries, runs); err != nil {
 			return models.InResponse{}, err
 		}
 	}
 
-	for _, summary := range request.Params.Summaries {
-		if err = g.JunitViewer.PrintSummary(summary); err != nil {
-			return models.InResponse{}, err
+	metadata := healthMetadata(runs)
+
+	// include the metadata recorded by the put which produced the
+	// requested version, if it was downloaded
+	for _, run := range runs {
+		if run.Key != request.Version.Key {
+			continue
+		}
+		for name, value := range run.Suites.Metadata() {
+			if _, ok := metadata[name]; !ok {
+				metadata[name] = value
+			}
 		}
 	}
 
 	return models.InResponse{
-		Version: request.Version,
-		Metadata: map[string]string{
-			"test_suite_count": fmt.Sprintf("%d", len(keysToFetch)),
-		},
+		Version:  request.Version,
+		Metadata: metadata,
 	}, nil
 }
 
-type byFileNameRegex []string
+func (g Getter) fetch(key string, resultPath string) error {
+	// keys from a multi storage are namespaced by label
+	if history.KeySource(key) != "" {
+		if err := os.MkdirAll(filepath.Dir(resultPath), 0755); err != nil {
+			return err
+		}
+	}
 
-func (a byFileNameRegex) Len() int      { return len(a) }
-func (a byFileNameRegex) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
-func (a byFileNameRegex) Less(i, j int) bool {
-	iTime, err := keyToTimestamp(a[i])
+	f, err := os.Create(resultPath)
 	if err != nil {
-		panic(err)
+		return err
 	}
-	jTime, err := keyToTimestamp(a[j])
-	if err != nil {
-		panic(err)
+	defer f.Close()
+
+	if err = g.Storage.Get(key, f); err != nil {
+		return err
 	}
-	return iTime.Before(jTime)
+
+	return f.Close()
 }
 
-// TODO: extract
-func keyToTimestamp(key string) (time.Time, error) {
-	iMatches := keyRegex.FindStringSubmatch(key)
-	if len(iMatches) == 0 {
-		return time.Time{}, fmt.Errorf("invalid filename '%s'", key)
+// enoughRunsFetched reports whether every summary has seen Limit
+// matching runs. A summary without a Limit needs every run.
+func enoughRunsFetched(summaries []models.Summary, fetchedCount int, matchCounts []int) bool {
+	if len(summaries) == 0 {
+		return false
 	}
-	iTime, err := time.Parse(timeFormat, iMatches[1])
-	if err != nil {
-		return time.Time{}, fmt.Errorf("invalid timestamp '%s'", key)
+	for i, summary := range summaries {
+		count := fetchedCount
+		if history.IsFiltered(summary.Filter) {
+			count = matchCounts[i]
+		}
+		if summary.Limit == 0 || count < summary.Limit {
+			return false
+		}
 	}
-
-	return iTime, nil
+	return true
 }
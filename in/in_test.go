@@ -12,8 +12,10 @@ import (
 
 	"github.com/ljfranklin/test-runner-resource/in"
 	"github.com/ljfranklin/test-runner-resource/models"
+	"github.com/ljfranklin/test-runner-resource/storage"
 	"github.com/ljfranklin/test-runner-resource/storage/storagefakes"
 	"github.com/ljfranklin/test-runner-resource/test/helpers"
+	"github.com/ljfranklin/test-runner-resource/viewer"
 	"github.com/ljfranklin/test-runner-resource/viewer/viewerfakes"
 )
 
@@ -50,6 +52,7 @@ func TestGet(t *testing.T) {
 		return nil
 	}
 	fakeJunit := &viewerfakes.FakeJunit{}
+	fakeReporter := &viewerfakes.FakeReporter{}
 
 	tmpDir, err := ioutil.TempDir("", "get-test")
 	if err != nil {
@@ -60,6 +63,7 @@ func TestGet(t *testing.T) {
 	getter := in.Getter{
 		Storage:     fakeStorage,
 		JunitViewer: fakeJunit,
+		Reporters:   []viewer.Reporter{fakeReporter},
 	}
 
 	requestedVersion := models.Version{
@@ -86,22 +90,48 @@ func TestGet(t *testing.T) {
 	}
 
 	helpers.AssertEquals(t, fakeJunit.PrintSummaryCallCount(), 2)
-	firstSummaryType := fakeJunit.PrintSummaryArgsForCall(0)
+	firstSummaryType, runs := fakeJunit.PrintSummaryArgsForCall(0)
 	helpers.AssertEquals(t, firstSummaryType, models.Summary{
 		Type:  "pass-fail",
 		Limit: 10,
 	})
-	secondSummaryType := fakeJunit.PrintSummaryArgsForCall(1)
+	helpers.AssertEquals(t, len(runs), 2)
+	helpers.AssertEquals(t, runs[0].Key, "test-results-2018-01-02T15:04:05Z.xml")
+	helpers.AssertEquals(t, runs[1].Key, "test-results-2018-01-01T15:04:05Z.xml")
+	secondSummaryType, secondRuns := fakeJunit.PrintSummaryArgsForCall(1)
 	helpers.AssertEquals(t, secondSummaryType, models.Summary{
 		Type:  "frequent-failures",
 		Limit: 5,
 	})
+	helpers.AssertEquals(t, secondRuns, runs)
+
+	helpers.AssertEquals(t, fakeReporter.WriteReportCallCount(), 1)
+	reportSummaries, reportRuns := fakeReporter.WriteReportArgsForCall(0)
+	helpers.AssertEquals(t, reportRuns, runs)
+	helpers.AssertEquals(t, reportSummaries, []models.Summary{
+		{
+			Type:  "pass-fail",
+			Limit: 10,
+		},
+		{
+			Type:  "frequent-failures",
+			Limit: 5,
+		},
+	})
 
 	helpers.AssertEquals(t, result.Version, models.Version{
 		Key: "test-results-2018-01-02T15:04:05Z.xml",
 	})
 	helpers.AssertEquals(t, result.Metadata, map[string]string{
 		"test_suite_count": "2",
+		"latest_tests":     "8",
+		"latest_failures":  "8",
+		"latest_errors":    "0",
+		"latest_skipped":   "0",
+		"latest_duration":  "0.003s",
+		"pass_rate":        "78.9%",
+		"flaky_test_count": "0",
+		"top_failing_test": "github.com/ljfranklin/test-runner-resource/storage: TestS3CompatibleDelete",
 	})
 
 	firstOutputXML := filepath.Join(tmpDir, "test-results-2018-01-01T15:04:05Z.xml")
@@ -141,6 +171,239 @@ func TestGet(t *testing.T) {
 	}
 }
 
+func TestGetReturnsRunMetadata(t *testing.T) {
+	fakeStorage := &storagefakes.FakeStorage{}
+	fakeStorage.ListReturns([]string{
+		"test-results-2018-01-02T15:04:05Z.xml",
+		"test-results-2018-01-01T15:04:05Z.xml",
+	}, nil)
+	fakeStorage.GetStub = func(key string, writer io.Writer) error {
+		fixture := "success.xml"
+		if key == "test-results-2018-01-02T15:04:05Z.xml" {
+			fixture = "metadata.xml"
+		}
+		f, err := os.Open(filepath.Join("..", "fixtures", "junit", fixture))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		_, err = io.Copy(writer, f)
+		return err
+	}
+	fakeJunit := &viewerfakes.FakeJunit{}
+
+	tmpDir, err := ioutil.TempDir("", "get-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	getter := in.Getter{
+		Storage:     fakeStorage,
+		JunitViewer: fakeJunit,
+	}
+
+	result, err := getter.Get(models.InRequest{
+		Version: models.Version{
+			Key: "test-results-2018-01-02T15:04:05Z.xml",
+		},
+		OutputDir: tmpDir,
+		Params: models.InParams{
+			Summaries: []models.Summary{
+				{
+					Type:  "pass-fail",
+					Limit: 10,
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	helpers.AssertEquals(t, result.Metadata, map[string]string{
+		"test_suite_count": "2",
+		"latest_tests":     "2",
+		"latest_failures":  "1",
+		"latest_errors":    "0",
+		"latest_skipped":   "0",
+		"latest_duration":  "95.250s",
+		"pass_rate":        "96.9%",
+		"flaky_test_count": "0",
+		"top_failing_test": "github.com/ljfranklin/test-runner-resource/integration: TestScale",
+		"branch":           "main",
+		"build_job_name":   "integration",
+		"build_name":       "42",
+		"iaas":             "azure",
+	})
+}
+
+func TestGetFetchesUntilFilteredLimitSatisfied(t *testing.T) {
+	fakeStorage := &storagefakes.FakeStorage{}
+	fakeStorage.ListReturns([]string{
+		"test-results-2018-01-04T15:04:05Z.xml",
+		"test-results-2018-01-03T15:04:05Z.xml",
+		"test-results-2018-01-02T15:04:05Z.xml",
+		"test-results-2018-01-01T15:04:05Z.xml",
+	}, nil)
+	fakeStorage.GetStub = func(key string, writer io.Writer) error {
+		fixture := "success.xml"
+		if key == "test-results-2018-01-02T15:04:05Z.xml" {
+			fixture = "metadata.xml"
+		}
+		f, err := os.Open(filepath.Join("..", "fixtures", "junit", fixture))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		_, err = io.Copy(writer, f)
+		return err
+	}
+	fakeJunit := &viewerfakes.FakeJunit{}
+
+	tmpDir, err := ioutil.TempDir("", "get-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	getter := in.Getter{
+		Storage:     fakeStorage,
+		JunitViewer: fakeJunit,
+	}
+
+	result, err := getter.Get(models.InRequest{
+		Version: models.Version{
+			Key: "test-results-2018-01-04T15:04:05Z.xml",
+		},
+		OutputDir: tmpDir,
+		Params: models.InParams{
+			Summaries: []models.Summary{
+				{
+					Type:  "pass-fail",
+					Limit: 1,
+					Filter: models.Filter{
+						Metadata: map[string]string{
+							"iaas": "azure",
+						},
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	helpers.AssertEquals(t, fakeStorage.GetCallCount(), 3)
+	helpers.AssertEquals(t, result.Metadata["test_suite_count"], "3")
+}
+
+func TestGetWithDisabledStorage(t *testing.T) {
+	fakeJunit := &viewerfakes.FakeJunit{}
+	fakeReporter := &viewerfakes.FakeReporter{}
+
+	tmpDir, err := ioutil.TempDir("", "get-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	getter := in.Getter{
+		Storage:     storage.NewDisabled(),
+		JunitViewer: fakeJunit,
+		Reporters:   []viewer.Reporter{fakeReporter},
+	}
+
+	result, err := getter.Get(models.InRequest{
+		Version: models.Version{
+			Key: "test-results-2018-01-02T15:04:05Z.xml",
+		},
+		OutputDir: tmpDir,
+		Params: models.InParams{
+			Summaries: []models.Summary{
+				{
+					Type: "pass-fail",
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	helpers.AssertEquals(t, fakeJunit.PrintSummaryCallCount(), 0)
+	helpers.AssertEquals(t, fakeReporter.WriteReportCallCount(), 0)
+	helpers.AssertEquals(t, result, models.InResponse{
+		Version: models.Version{
+			Key: "test-results-2018-01-02T15:04:05Z.xml",
+		},
+		Metadata: map[string]string{
+			"test_suite_count": "0",
+			"storage":          "disabled",
+		},
+	})
+}
+
+func TestGetWithNamespacedKeys(t *testing.T) {
+	fakeStorage := &storagefakes.FakeStorage{}
+	fakeStorage.ListReturns([]string{
+		"team-a/test-results-2018-01-03T15:04:05Z.xml",
+		"team-b/test-results-2018-01-02T15:04:05Z.xml",
+		"team-a/test-results-2018-01-01T15:04:05Z.xml",
+	}, nil)
+	fakeStorage.GetStub = func(key string, writer io.Writer) error {
+		f, err := os.Open(filepath.Join("..", "fixtures", "junit", "success.xml"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		_, err = io.Copy(writer, f)
+		return err
+	}
+	fakeJunit := &viewerfakes.FakeJunit{}
+
+	tmpDir, err := ioutil.TempDir("", "get-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	getter := in.Getter{
+		Storage:     fakeStorage,
+		JunitViewer: fakeJunit,
+	}
+
+	_, err = getter.Get(models.InRequest{
+		Version: models.Version{
+			Key: "team-a/test-results-2018-01-03T15:04:05Z.xml",
+		},
+		OutputDir: tmpDir,
+		Params: models.InParams{
+			Summaries: []models.Summary{
+				{
+					Type:   "pass-fail",
+					Limit:  1,
+					Filter: models.Filter{Source: "team-b"},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	helpers.AssertEquals(t, fakeStorage.GetCallCount(), 2)
+	for _, key := range []string{
+		"team-a/test-results-2018-01-03T15:04:05Z.xml",
+		"team-b/test-results-2018-01-02T15:04:05Z.xml",
+	} {
+		if _, err = os.Stat(filepath.Join(tmpDir, key)); err != nil {
+			t.Fatalf("expected '%s' to be downloaded: %s", key, err)
+		}
+	}
+}
+
 func TestGetLimitFileDownloads(t *testing.T) {
 	fakeStorage := &storagefakes.FakeStorage{}
 	fakeStorage.ListReturns([]string{
@@ -396,6 +659,15 @@ func TestGetErrorOnPrintSummaryFail(t *testing.T) {
 	fakeStorage.ListReturns([]string{
 		"test-results-2018-01-02T15:04:05Z.xml",
 	}, nil)
+	fakeStorage.GetStub = func(key string, writer io.Writer) error {
+		f, err := os.Open(filepath.Join("..", "fixtures", "junit", "success.xml"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		_, err = io.Copy(writer, f)
+		return err
+	}
 
 	fakeJunit := &viewerfakes.FakeJunit{}
 	fakeJunit.PrintSummaryReturns(errors.New("some-error"))
@@ -433,3 +705,57 @@ func TestGetErrorOnPrintSummaryFail(t *testing.T) {
 		t.Fatalf("expected err to contain 'some-error', but it did not: %s", err)
 	}
 }
+
+func TestGetErrorOnWriteReportFail(t *testing.T) {
+	fakeStorage := &storagefakes.FakeStorage{}
+	fakeStorage.ListReturns([]string{
+		"test-results-2018-01-02T15:04:05Z.xml",
+	}, nil)
+	fakeStorage.GetStub = func(key string, writer io.Writer) error {
+		f, err := os.Open(filepath.Join("..", "fixtures", "junit", "success.xml"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		_, err = io.Copy(writer, f)
+		return err
+	}
+
+	fakeJunit := &viewerfakes.FakeJunit{}
+	fakeReporter := &viewerfakes.FakeReporter{}
+	fakeReporter.WriteReportReturns(errors.New("some-error"))
+
+	tmpDir, err := ioutil.TempDir("", "get-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	getter := in.Getter{
+		Storage:     fakeStorage,
+		JunitViewer: fakeJunit,
+		Reporters:   []viewer.Reporter{fakeReporter},
+	}
+
+	requestedVersion := models.Version{
+		Key: "test-results-2018-01-02T15:04:05Z.xml",
+	}
+	_, err = getter.Get(models.InRequest{
+		Version:   requestedVersion,
+		OutputDir: tmpDir,
+		Params: models.InParams{
+			Summaries: []models.Summary{
+				{
+					Type:  "pass-fail",
+					Limit: 10,
+				},
+			},
+		},
+	})
+	if err == nil {
+		t.Fatal("expected err to occur but it did not")
+	}
+	if !strings.Contains(err.Error(), "some-error") {
+		t.Fatalf("expected err to contain 'some-error', but it did not: %s", err)
+	}
+}
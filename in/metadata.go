@@ -0,0 +1,56 @@
+package in
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/ljfranklin/test-runner-resource/history"
+	"github.com/ljfranklin/test-runner-resource/junit"
+)
+
+func loadFetchedRun(outputDir string, key string) (history.Run, error) {
+	timestamp, err := history.KeyToTimestamp(key)
+	if err != nil {
+		return history.Run{}, err
+	}
+	suites, err := junit.ParseFile(filepath.Join(outputDir, key))
+	if err != nil {
+		return history.Run{}, err
+	}
+	return history.Run{
+		Key:       key,
+		Timestamp: timestamp,
+		Suites:    suites,
+		Source:    history.KeySource(key),
+	}, nil
+}
+
+// healthMetadata summarizes the fetched window for display on the
+// Concourse build page. runs must be ordered from newest to oldest.
+func healthMetadata(runs []history.Run) map[string]string {
+	metadata := map[string]string{
+		"test_suite_count": fmt.Sprintf("%d", len(runs)),
+	}
+	if len(runs) == 0 {
+		return metadata
+	}
+
+	latest := runs[0].Suites.Totals()
+	metadata["latest_tests"] = fmt.Sprintf("%d", latest.Tests)
+	metadata["latest_failures"] = fmt.Sprintf("%d", latest.Failures)
+	metadata["latest_errors"] = fmt.Sprintf("%d", latest.Errors)
+	metadata["latest_skipped"] = fmt.Sprintf("%d", latest.Skipped)
+	metadata["latest_duration"] = fmt.Sprintf("%.3fs", latest.Time)
+
+	if passRate, ok := history.PassRate(runs); ok {
+		metadata["pass_rate"] = fmt.Sprintf("%.1f%%", passRate)
+	}
+
+	metadata["flaky_test_count"] = fmt.Sprintf("%d", len(history.Flaky(runs)))
+
+	if failures := history.FrequentFailures(runs, nil); len(failures) > 0 {
+		metadata["top_failing_test"] = failures[0].Test.String()
+	}
+
+	return metadata
+}
@@ -0,0 +1,287 @@
+package junit
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+const (
+	StatusPassed  = "passed"
+	StatusFailed  = "failed"
+	StatusError   = "error"
+	StatusSkipped = "skipped"
+)
+
+type TestSuites struct {
+	XMLName    xml.Name    `xml:"testsuites"`
+	Name       string      `xml:"name,attr,omitempty"`
+	Tests      int         `xml:"tests,attr"`
+	Failures   int         `xml:"failures,attr"`
+	Errors     int         `xml:"errors,attr"`
+	Skipped    int         `xml:"skipped,attr"`
+	Time       float64     `xml:"time,attr"`
+	Properties *Properties `xml:"properties"`
+	TestSuites []TestSuite `xml:"testsuite"`
+}
+
+type TestSuite struct {
+	XMLName    xml.Name    `xml:"testsuite"`
+	Name       string      `xml:"name,attr"`
+	Package    string      `xml:"package,attr,omitempty"`
+	ID         string      `xml:"id,attr,omitempty"`
+	Hostname   string      `xml:"hostname,attr,omitempty"`
+	Timestamp  string      `xml:"timestamp,attr,omitempty"`
+	Tests      int         `xml:"tests,attr"`
+	Failures   int         `xml:"failures,attr"`
+	Errors     int         `xml:"errors,attr"`
+	Skipped    int         `xml:"skipped,attr"`
+	Time       float64     `xml:"time,attr"`
+	Properties *Properties `xml:"properties"`
+	TestCases  []TestCase  `xml:"testcase"`
+	// some producers nest suites, e.g. one per package
+	TestSuites []TestSuite `xml:"testsuite"`
+	SystemOut  *SystemOut  `xml:"system-out"`
+	SystemErr  *SystemErr  `xml:"system-err"`
+}
+
+type Properties struct {
+	Properties []Property `xml:"property"`
+}
+
+type Property struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type TestCase struct {
+	Name       string     `xml:"name,attr"`
+	ClassName  string     `xml:"classname,attr"`
+	Assertions string     `xml:"assertions,attr,omitempty"`
+	Time       float64    `xml:"time,attr"`
+	Failure    *Failure   `xml:"failure"`
+	Error      *Error     `xml:"error"`
+	Skipped    *Skipped   `xml:"skipped"`
+	SystemOut  *SystemOut `xml:"system-out"`
+	SystemErr  *SystemErr `xml:"system-err"`
+}
+
+type Failure struct {
+	Message  string `xml:"message,attr,omitempty"`
+	Type     string `xml:"type,attr,omitempty"`
+	Contents string `xml:",chardata"`
+}
+
+type Error struct {
+	Message  string `xml:"message,attr,omitempty"`
+	Type     string `xml:"type,attr,omitempty"`
+	Contents string `xml:",chardata"`
+}
+
+type Skipped struct {
+	Message  string `xml:"message,attr,omitempty"`
+	Contents string `xml:",chardata"`
+}
+
+type SystemOut struct {
+	Contents string `xml:",chardata"`
+}
+
+type SystemErr struct {
+	Contents string `xml:",chardata"`
+}
+
+func (t TestCase) Status() string {
+	switch {
+	case t.Error != nil:
+		return StatusError
+	case t.Failure != nil:
+		return StatusFailed
+	case t.Skipped != nil:
+		return StatusSkipped
+	default:
+		return StatusPassed
+	}
+}
+
+// Parse accepts documents rooted at either `testsuites` or `testsuite`.
+// A bare `testsuite` is wrapped in a parent `testsuites`.
+func Parse(r io.Reader) (TestSuites, error) {
+	decoder := xml.NewDecoder(r)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return TestSuites{}, fmt.Errorf("found no root element")
+		}
+		if err != nil {
+			return TestSuites{}, fmt.Errorf("unable to parse XML: %s", err)
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch start.Name.Local {
+		case "testsuites":
+			var suites TestSuites
+			if err = decoder.DecodeElement(&suites, &start); err != nil {
+				return TestSuites{}, fmt.Errorf("unable to parse XML: %s", err)
+			}
+			return suites, nil
+		case "testsuite":
+			var suite TestSuite
+			if err = decoder.DecodeElement(&suite, &start); err != nil {
+				return TestSuites{}, fmt.Errorf("unable to parse XML: %s", err)
+			}
+			return TestSuites{
+				XMLName:    xml.Name{Local: "testsuites"},
+				Tests:      suite.Tests,
+				Failures:   suite.Failures,
+				Errors:     suite.Errors,
+				Skipped:    suite.Skipped,
+				Time:       suite.Time,
+				TestSuites: []TestSuite{suite},
+			}, nil
+		default:
+			return TestSuites{}, fmt.Errorf("unexpected root element '%s'; expected 'testsuites' or 'testsuite'", start.Name.Local)
+		}
+	}
+}
+
+func ParseFile(path string) (TestSuites, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return TestSuites{}, err
+	}
+	defer f.Close()
+
+	suites, err := Parse(f)
+	if err != nil {
+		return TestSuites{}, fmt.Errorf("failed to parse '%s': %s", path, err)
+	}
+	return suites, nil
+}
+
+// Metadata returns the properties recorded on the root element.
+func (t TestSuites) Metadata() map[string]string {
+	metadata := map[string]string{}
+	if t.Properties == nil {
+		return metadata
+	}
+	for _, property := range t.Properties.Properties {
+		metadata[property.Name] = property.Value
+	}
+	return metadata
+}
+
+func (t *TestSuites) SetMetadata(metadata map[string]string) {
+	if len(metadata) == 0 {
+		t.Properties = nil
+		return
+	}
+
+	names := []string{}
+	for name := range metadata {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	t.Properties = &Properties{}
+	for _, name := range names {
+		t.Properties.Properties = append(t.Properties.Properties, Property{
+			Name:  name,
+			Value: metadata[name],
+		})
+	}
+}
+
+type Totals struct {
+	Tests    int
+	Failures int
+	Errors   int
+	Skipped  int
+	Time     float64
+}
+
+func (t Totals) Passed() int {
+	return t.Tests - t.Failures - t.Errors - t.Skipped
+}
+
+// Walk calls fn for every test case, including those in nested suites.
+func (t TestSuites) Walk(fn func(TestSuite, TestCase)) {
+	for _, suite := range t.TestSuites {
+		suite.walk(fn)
+	}
+}
+
+func (t TestSuite) walk(fn func(TestSuite, TestCase)) {
+	for _, testCase := range t.TestCases {
+		fn(t, testCase)
+	}
+	for _, nested := range t.TestSuites {
+		nested.walk(fn)
+	}
+}
+
+// Totals counts test cases directly rather than trusting the
+// aggregate attributes, which some producers omit or get wrong.
+func (t TestSuites) Totals() Totals {
+	totals := Totals{}
+	t.Walk(func(_ TestSuite, testCase TestCase) {
+		totals.Tests++
+		switch testCase.Status() {
+		case StatusFailed:
+			totals.Failures++
+		case StatusError:
+			totals.Errors++
+		case StatusSkipped:
+			totals.Skipped++
+		}
+	})
+
+	totals.Time = t.Time
+	if totals.Time == 0 {
+		for _, suite := range t.TestSuites {
+			totals.Time += suite.Time
+		}
+	}
+	return totals
+}
+
+// Merge nests every suite from documents under a single parent
+// and recomputes the aggregate counts on the root.
+func Merge(documents []TestSuites) TestSuites {
+	merged := TestSuites{
+		TestSuites: []TestSuite{},
+	}
+	for _, document := range documents {
+		merged.TestSuites = append(merged.TestSuites, document.TestSuites...)
+	}
+
+	totals := merged.Totals()
+	merged.Tests = totals.Tests
+	merged.Failures = totals.Failures
+	merged.Errors = totals.Errors
+	merged.Skipped = totals.Skipped
+	merged.Time = totals.Time
+
+	return merged
+}
+
+func (t TestSuites) Write(w io.Writer) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "\t")
+	if err := encoder.Encode(t); err != nil {
+		return fmt.Errorf("unable to encode XML: %s", err)
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
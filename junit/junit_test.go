@@ -0,0 +1,219 @@
+package junit_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ljfranklin/test-runner-resource/junit"
+	"github.com/ljfranklin/test-runner-resource/test/helpers"
+)
+
+func fixturePath(fixture string) string {
+	return filepath.Join("..", "fixtures", "junit", fixture)
+}
+
+func TestParseTestSuitesRoot(t *testing.T) {
+	suites, err := junit.ParseFile(fixturePath("failures.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	helpers.AssertEquals(t, len(suites.TestSuites), 1)
+	suite := suites.TestSuites[0]
+	helpers.AssertEquals(t, suite.Name, "github.com/ljfranklin/test-runner-resource/storage")
+	helpers.AssertEquals(t, suite.Tests, 8)
+	helpers.AssertEquals(t, suite.Failures, 8)
+	helpers.AssertEquals(t, suite.Time, 0.003)
+	helpers.AssertEquals(t, suite.Properties.Properties, []junit.Property{
+		{
+			Name:  "go.version",
+			Value: "go1.9.2",
+		},
+	})
+	helpers.AssertEquals(t, len(suite.TestCases), 8)
+	helpers.AssertEquals(t, suite.TestCases[0].Name, "TestS3Get")
+	helpers.AssertEquals(t, suite.TestCases[0].ClassName, "storage")
+	helpers.AssertEquals(t, suite.TestCases[0].Failure, &junit.Failure{
+		Message:  "Failed",
+		Contents: "s3_test.go:102: AWS_ACCESS_KEY must be set",
+	})
+}
+
+func TestParseTestSuiteRoot(t *testing.T) {
+	suites, err := junit.ParseFile(fixturePath("testsuite-root.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	helpers.AssertEquals(t, suites.Tests, 4)
+	helpers.AssertEquals(t, suites.Time, 12.5)
+	helpers.AssertEquals(t, len(suites.TestSuites), 1)
+
+	suite := suites.TestSuites[0]
+	helpers.AssertEquals(t, suite.Name, "integration")
+	helpers.AssertEquals(t, suite.SystemOut, &junit.SystemOut{Contents: "starting integration suite"})
+	helpers.AssertEquals(t, suite.SystemErr, &junit.SystemErr{Contents: "some warning"})
+
+	statuses := []string{}
+	for _, testCase := range suite.TestCases {
+		statuses = append(statuses, testCase.Status())
+	}
+	helpers.AssertEquals(t, statuses, []string{
+		junit.StatusPassed,
+		junit.StatusFailed,
+		junit.StatusError,
+		junit.StatusSkipped,
+	})
+	helpers.AssertEquals(t, suite.TestCases[1].SystemOut, &junit.SystemOut{Contents: "scaling to 3 instances"})
+	helpers.AssertEquals(t, suite.TestCases[2].Error, &junit.Error{
+		Message:  "panic",
+		Type:     "runtime.Error",
+		Contents: "502 Bad Gateway",
+	})
+	helpers.AssertEquals(t, suite.TestCases[3].Skipped, &junit.Skipped{Message: "requires postgres"})
+}
+
+func TestParseErrorOnUnexpectedRoot(t *testing.T) {
+	_, err := junit.Parse(strings.NewReader(`<html></html>`))
+	if err == nil {
+		t.Fatal("expected err to occur but it did not")
+	}
+	if !strings.Contains(err.Error(), "html") {
+		t.Fatalf("expected err to contain 'html', but it did not: %s", err)
+	}
+}
+
+func TestParseErrorOnEmptyDocument(t *testing.T) {
+	_, err := junit.Parse(strings.NewReader(``))
+	if err == nil {
+		t.Fatal("expected err to occur but it did not")
+	}
+}
+
+func TestParseFileErrorIncludesPath(t *testing.T) {
+	_, err := junit.ParseFile(fixturePath("some-missing-file.xml"))
+	if err == nil {
+		t.Fatal("expected err to occur but it did not")
+	}
+	if !strings.Contains(err.Error(), "some-missing-file.xml") {
+		t.Fatalf("expected err to contain 'some-missing-file.xml', but it did not: %s", err)
+	}
+}
+
+func TestTotalsIncludesNestedSuites(t *testing.T) {
+	suites, err := junit.Parse(strings.NewReader(`
+<testsuites>
+	<testsuite name="parent" time="3.5">
+		<testcase name="a"></testcase>
+		<testsuite name="child">
+			<testcase name="b"><failure>boom</failure></testcase>
+			<testcase name="c"><skipped></skipped></testcase>
+		</testsuite>
+	</testsuite>
+</testsuites>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	totals := suites.Totals()
+	helpers.AssertEquals(t, totals, junit.Totals{
+		Tests:    3,
+		Failures: 1,
+		Skipped:  1,
+		Time:     3.5,
+	})
+	helpers.AssertEquals(t, totals.Passed(), 1)
+
+	names := []string{}
+	suites.Walk(func(suite junit.TestSuite, testCase junit.TestCase) {
+		names = append(names, suite.Name+"/"+testCase.Name)
+	})
+	helpers.AssertEquals(t, names, []string{"parent/a", "child/b", "child/c"})
+}
+
+func TestMerge(t *testing.T) {
+	success, err := junit.ParseFile(fixturePath("success.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bareSuite, err := junit.ParseFile(fixturePath("testsuite-root.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	merged := junit.Merge([]junit.TestSuites{success, bareSuite})
+
+	helpers.AssertEquals(t, len(merged.TestSuites), 2)
+	helpers.AssertEquals(t, merged.Tests, 34)
+	helpers.AssertEquals(t, merged.Failures, 1)
+	helpers.AssertEquals(t, merged.Errors, 1)
+	helpers.AssertEquals(t, merged.Skipped, 1)
+	helpers.AssertEquals(t, merged.Time, 9.837+12.5)
+}
+
+func TestWriteRoundTrips(t *testing.T) {
+	original, err := junit.ParseFile(fixturePath("testsuite-root.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	contents := bytes.Buffer{}
+	if err = original.Write(&contents); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(contents.String(), "<?xml") {
+		t.Fatalf("expected output to start with XML header but it did not: %s", contents.String())
+	}
+
+	parsed, err := junit.Parse(&contents)
+	if err != nil {
+		t.Fatal(err)
+	}
+	helpers.AssertEquals(t, parsed, original)
+}
+
+func TestMetadata(t *testing.T) {
+	suites, err := junit.ParseFile(fixturePath("metadata.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	helpers.AssertEquals(t, suites.Metadata(), map[string]string{
+		"branch":         "main",
+		"build_job_name": "integration",
+		"build_name":     "42",
+		"iaas":           "azure",
+	})
+}
+
+func TestSetMetadataRoundTrips(t *testing.T) {
+	suites, err := junit.ParseFile(fixturePath("success.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	helpers.AssertEquals(t, suites.Metadata(), map[string]string{})
+
+	suites.SetMetadata(map[string]string{
+		"iaas":   "azure",
+		"branch": "main",
+	})
+	helpers.AssertEquals(t, suites.Properties.Properties, []junit.Property{
+		{Name: "branch", Value: "main"},
+		{Name: "iaas", Value: "azure"},
+	})
+
+	contents := bytes.Buffer{}
+	if err = suites.Write(&contents); err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := junit.Parse(&contents)
+	if err != nil {
+		t.Fatal(err)
+	}
+	helpers.AssertEquals(t, parsed.Metadata(), map[string]string{
+		"iaas":   "azure",
+		"branch": "main",
+	})
+}
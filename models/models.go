@@ -35,4 +35,137 @@ type InResponse struct {
 type Summary struct {
 	Type  string `json:"type"`
 	Limit int    `json:"limit"`
+	// number of tests to list in ranked summaries, defaults to 10
+	Top    int    `json:"top"`
+	Filter Filter `json:"filter"`
+	// regex matched against the output of failing test cases
+	OutputMatches string `json:"output_matches"`
+	// percentage above the median duration which counts as a
+	// regression, defaults to 50
+	RegressionThreshold float64 `json:"regression_threshold"`
+	// seconds above the median duration which a regression must also
+	// exceed, avoiding noise from fast tests, defaults to 0.5
+	RegressionMinSeconds float64 `json:"regression_min_seconds"`
+	// report each storage label of a `multi` storage separately
+	BySource bool `json:"by_source"`
+}
+
+type Filter struct {
+	Metadata map[string]string `json:"metadata"`
+	// shorthand for metadata `build_job_name`
+	Job string `json:"job"`
+	// label of a storage configured with storage_type `multi`
+	Source string `json:"source"`
+}
+
+type OutRequest struct {
+	Source    Source    `json:"source"`
+	Params    OutParams `json:"params"`
+	SourceDir string    `json:"-"`
+}
+
+type OutParams struct {
+	// "docker" (default) or "local" to run command in the resource
+	// container itself
+	Runner      string `json:"runner"`
+	DockerImage string `json:"docker_image"`
+	// an image saved by a `get` step, e.g. `ci-image/image`
+	DockerImagePath string `json:"docker_image_path"`
+	Command         string `json:"command"`
+	// defaults to "sh", run as `<shell> -c <command>`
+	Shell string `json:"shell"`
+	// relative to the put's working directory
+	WorkDir string            `json:"workdir"`
+	Env     map[string]string `json:"env"`
+	// e.g. "30m", only supported by the local runner
+	Timeout       string            `json:"timeout"`
+	ResultsType   string            `json:"results_type"`
+	ResultsConfig ResultsConfig     `json:"results_config"`
+	Metadata      map[string]string `json:"metadata"`
+	// the label to upload to, required with multi storage
+	Source string `json:"source"`
+	// summaries of the current run to print after uploading
+	Summaries []Summary `json:"summaries"`
+}
+
+type ResultsConfig struct {
+	Path string `json:"path"`
+}
+
+type OutResponse struct {
+	Version  Version           `json:"version"`
+	Metadata map[string]string `json:"metadata"`
+}
+
+// SummaryJSONVersion is bumped on any incompatible change to
+// SummaryJSON so consumers can detect which schema they are reading.
+const SummaryJSONVersion = 1
+
+// SummaryJSON is the schema of the `summary.json` file written by `in`.
+type SummaryJSON struct {
+	Version int `json:"version"`
+	// every fetched run, newest first
+	Runs      []RunTotals     `json:"runs"`
+	Tests     []TestHistory   `json:"tests"`
+	Summaries []SummaryResult `json:"summaries"`
+}
+
+type RunTotals struct {
+	Key       string            `json:"key"`
+	Source    string            `json:"source,omitempty"`
+	Timestamp string            `json:"timestamp"`
+	Tests     int               `json:"tests"`
+	Passed    int               `json:"passed"`
+	Failures  int               `json:"failures"`
+	Errors    int               `json:"errors"`
+	Skipped   int               `json:"skipped"`
+	Time      float64           `json:"time"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+}
+
+type TestRef struct {
+	Suite     string `json:"suite"`
+	ClassName string `json:"classname"`
+	Name      string `json:"name"`
+}
+
+type TestHistory struct {
+	Test TestRef `json:"test"`
+	// one entry per run the test appeared in, newest first
+	Results []TestResult `json:"results"`
+}
+
+type TestResult struct {
+	Key    string  `json:"key"`
+	Status string  `json:"status"`
+	Time   float64 `json:"time"`
+}
+
+// SummaryResult holds the rankings for one requested summary,
+// computed over the runs which matched its filter and limit.
+type SummaryResult struct {
+	Summary          Summary           `json:"summary"`
+	RunKeys          []string          `json:"run_keys"`
+	Flaky            []FlakyTest       `json:"flaky"`
+	FrequentFailures []FrequentFailure `json:"frequent_failures"`
+}
+
+type FlakyTest struct {
+	Test      TestRef `json:"test"`
+	Runs      int     `json:"runs"`
+	Passes    int     `json:"passes"`
+	Failures  int     `json:"failures"`
+	Flips     int     `json:"flips"`
+	Score     float64 `json:"score"`
+	LatestKey string  `json:"latest_key"`
+}
+
+type FrequentFailure struct {
+	Test        TestRef `json:"test"`
+	Runs        int     `json:"runs"`
+	Failures    int     `json:"failures"`
+	FailureRate float64 `json:"failure_rate"`
+	FirstKey    string  `json:"first_key"`
+	LatestKey   string  `json:"latest_key"`
+	Message     string  `json:"message"`
 }
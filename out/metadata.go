@@ -0,0 +1,64 @@
+package out
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// Concourse exposes these to resource containers,
+// see https://concourse-ci.org/implementing-resources.html#resource-metadata
+var buildEnvVars = map[string]string{
+	"BUILD_ID":            "build_id",
+	"BUILD_NAME":          "build_name",
+	"BUILD_JOB_NAME":      "build_job_name",
+	"BUILD_PIPELINE_NAME": "build_pipeline_name",
+	"BUILD_TEAM_NAME":     "build_team_name",
+	"ATC_EXTERNAL_URL":    "atc_external_url",
+}
+
+func (p Putter) runMetadata(imageMetadata map[string]string, userMetadata map[string]string) map[string]string {
+	getenv := p.Getenv
+	if getenv == nil {
+		getenv = os.Getenv
+	}
+
+	metadata := map[string]string{}
+	for envVar, name := range buildEnvVars {
+		if value := getenv(envVar); value != "" {
+			metadata[name] = value
+		}
+	}
+	if buildURL := buildURL(metadata); buildURL != "" {
+		metadata["build_url"] = buildURL
+	}
+	for name, value := range imageMetadata {
+		if value != "" {
+			metadata[name] = value
+		}
+	}
+
+	// explicit params take precedence over the build environment
+	for name, value := range userMetadata {
+		metadata[name] = value
+	}
+
+	return metadata
+}
+
+func buildURL(metadata map[string]string) string {
+	for _, required := range []string{"atc_external_url", "build_team_name", "build_pipeline_name", "build_job_name", "build_name"} {
+		if metadata[required] == "" {
+			return ""
+		}
+	}
+
+	return fmt.Sprintf("%s/teams/%s/pipelines/%s/jobs/%s/builds/%s",
+		strings.TrimSuffix(metadata["atc_external_url"], "/"),
+		url.PathEscape(metadata["build_team_name"]),
+		url.PathEscape(metadata["build_pipeline_name"]),
+		url.PathEscape(metadata["build_job_name"]),
+		url.PathEscape(metadata["build_name"]),
+	)
+}
@@ -0,0 +1,229 @@
+package out
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ljfranklin/test-runner-resource/history"
+	"github.com/ljfranklin/test-runner-resource/junit"
+	"github.com/ljfranklin/test-runner-resource/models"
+	"github.com/ljfranklin/test-runner-resource/runner"
+	"github.com/ljfranklin/test-runner-resource/storage"
+	"github.com/ljfranklin/test-runner-resource/viewer"
+)
+
+const (
+	defaultResultsType = "junit"
+	dockerRunner       = "docker"
+	localRunner        = "local"
+)
+
+type Putter struct {
+	Storage     storage.Storage
+	Runner      runner.Runner
+	JunitViewer viewer.Junit
+	// defaults to time.Now
+	Now func() time.Time
+	// defaults to os.Getenv
+	Getenv func(string) string
+}
+
+func (p Putter) Put(request models.OutRequest) (models.OutResponse, error) {
+	if err := validateParams(request.Params); err != nil {
+		return models.OutResponse{}, err
+	}
+	// checked before running the command so a long test run is
+	// never discarded due to a bad key
+	if err := validateSource(request.Params.Source, p.Storage); err != nil {
+		return models.OutResponse{}, err
+	}
+
+	imageMetadata := map[string]string{}
+	runConfig := runner.Config{
+		Image:     request.Params.DockerImage,
+		Command:   request.Params.Command,
+		SourceDir: request.SourceDir,
+		WorkDir:   filepath.Join(request.SourceDir, request.Params.WorkDir),
+		Shell:     request.Params.Shell,
+		Env:       request.Params.Env,
+	}
+	if request.Params.Timeout != "" {
+		// already checked by validateParams
+		runConfig.Timeout, _ = time.ParseDuration(request.Params.Timeout)
+	}
+	if request.Params.DockerImagePath != "" {
+		savedImage, err := runner.LocateSavedImage(filepath.Join(request.SourceDir, request.Params.DockerImagePath))
+		if err != nil {
+			return models.OutResponse{}, err
+		}
+		runConfig.SavedImage = &savedImage
+		// ties the results to the exact image which produced them
+		imageMetadata["docker_image_digest"] = savedImage.Digest
+		if savedImage.Tag != "" {
+			imageMetadata["docker_image"] = savedImage.Tag
+		}
+	}
+
+	startTime := p.now()
+	runErr := p.Runner.Run(runConfig)
+	elapsed := p.now().Sub(startTime)
+	if _, ok := runErr.(runner.CommandFailed); runErr != nil && !ok {
+		return models.OutResponse{}, runErr
+	}
+
+	results, err := collectResults(request.SourceDir, request.Params.ResultsConfig.Path, request.Params.ResultsType)
+	if err != nil {
+		if runErr != nil {
+			return models.OutResponse{}, fmt.Errorf("test command failed: %s; %s", runErr, err)
+		}
+		return models.OutResponse{}, err
+	}
+
+	results.Time = elapsed.Seconds()
+	results.SetMetadata(p.runMetadata(imageMetadata, request.Params.Metadata))
+
+	key := history.TimestampToKey(startTime)
+	if request.Params.Source != "" {
+		key = request.Params.Source + "/" + key
+	}
+	if err = p.upload(key, results); err != nil {
+		return models.OutResponse{}, err
+	}
+
+	if err = p.printSummaries(key, results, request.Params.Summaries); err != nil {
+		return models.OutResponse{}, err
+	}
+
+	response := models.OutResponse{
+		Version: models.Version{
+			Key: key,
+		},
+		Metadata: map[string]string{},
+	}
+	if runErr != nil {
+		return response, fmt.Errorf("test command failed: %s", runErr)
+	}
+
+	return response, nil
+}
+
+func (p Putter) upload(key string, results junit.TestSuites) error {
+	contents := bytes.Buffer{}
+	if err := results.Write(&contents); err != nil {
+		return err
+	}
+
+	return p.Storage.Put(key, &contents)
+}
+
+// printSummaries summarizes only the current run. With disabled
+// storage a pass-fail summary is printed by default as `get` has no
+// history to summarize.
+func (p Putter) printSummaries(key string, results junit.TestSuites, summaries []models.Summary) error {
+	if len(summaries) == 0 && storage.IsDisabled(p.Storage) {
+		summaries = []models.Summary{
+			{
+				Type: "pass-fail",
+			},
+		}
+	}
+	if len(summaries) == 0 || p.JunitViewer == nil {
+		return nil
+	}
+
+	timestamp, err := history.KeyToTimestamp(key)
+	if err != nil {
+		return err
+	}
+	runs := []history.Run{
+		{
+			Key:       key,
+			Timestamp: timestamp,
+			Suites:    results,
+			Source:    history.KeySource(key),
+		},
+	}
+	for _, summary := range summaries {
+		if err = p.JunitViewer.PrintSummary(summary, runs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p Putter) now() time.Time {
+	if p.Now == nil {
+		return time.Now()
+	}
+	return p.Now()
+}
+
+func validateSource(source string, s storage.Storage) error {
+	labels := storage.Labels(s)
+	if labels == nil {
+		if source != "" {
+			return fmt.Errorf("source is only supported with storage_type 'multi'")
+		}
+		return nil
+	}
+
+	for _, label := range labels {
+		if label == source {
+			return nil
+		}
+	}
+	if source == "" {
+		return fmt.Errorf("missing required params: source; set source to the multi storage label to upload to, one of: '%s'", strings.Join(labels, "', '"))
+	}
+	return fmt.Errorf("unrecognized source '%s'; set source to one of the following: '%s'", source, strings.Join(labels, "', '"))
+}
+
+func validateParams(params models.OutParams) error {
+	if params.Runner != "" && params.Runner != dockerRunner && params.Runner != localRunner {
+		return fmt.Errorf("unrecognized runner '%s'; set runner to one of the following: '%s', '%s'", params.Runner, dockerRunner, localRunner)
+	}
+
+	missingParams := []string{}
+	if params.DockerImage == "" && params.DockerImagePath == "" && params.Runner != localRunner {
+		missingParams = append(missingParams, "docker_image or docker_image_path")
+	}
+	if params.Command == "" {
+		missingParams = append(missingParams, "command")
+	}
+	if params.ResultsConfig.Path == "" {
+		missingParams = append(missingParams, "results_config.path")
+	}
+	if len(missingParams) > 0 {
+		return fmt.Errorf("missing required params: %s", strings.Join(missingParams, ", "))
+	}
+
+	if params.DockerImage != "" && params.DockerImagePath != "" {
+		return fmt.Errorf("docker_image and docker_image_path are mutually exclusive")
+	}
+	if params.DockerImagePath != "" && params.Runner == localRunner {
+		return fmt.Errorf("docker_image_path is only supported with runner '%s'", dockerRunner)
+	}
+
+	if _, ok := resultsParsers[params.ResultsType]; params.ResultsType != "" && !ok {
+		return unrecognizedResultsType(params.ResultsType)
+	}
+
+	// e.g. "../outside" but not "..cache"
+	workDir := filepath.ToSlash(filepath.Clean(params.WorkDir))
+	if filepath.IsAbs(params.WorkDir) || workDir == ".." || strings.HasPrefix(workDir, "../") {
+		return fmt.Errorf("workdir '%s' must be a path within the put's working directory", params.WorkDir)
+	}
+	if params.Timeout != "" {
+		if params.Runner != localRunner {
+			return fmt.Errorf("timeout is only supported with runner '%s'", localRunner)
+		}
+		if timeout, err := time.ParseDuration(params.Timeout); err != nil || timeout <= 0 {
+			return fmt.Errorf("invalid timeout '%s'; expected a positive duration such as '30m'", params.Timeout)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,1034 @@
+package out_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ljfranklin/test-runner-resource/junit"
+	"github.com/ljfranklin/test-runner-resource/models"
+	"github.com/ljfranklin/test-runner-resource/out"
+	"github.com/ljfranklin/test-runner-resource/runner"
+	"github.com/ljfranklin/test-runner-resource/runner/runnerfakes"
+	"github.com/ljfranklin/test-runner-resource/storage"
+	"github.com/ljfranklin/test-runner-resource/storage/storagefakes"
+	"github.com/ljfranklin/test-runner-resource/test/helpers"
+	"github.com/ljfranklin/test-runner-resource/viewer/viewerfakes"
+)
+
+func fixedTime() time.Time {
+	return time.Date(2018, 1, 2, 15, 4, 5, 123000000, time.UTC)
+}
+
+func copyFixtureToDir(t *testing.T, fixture string, dir string, filename string) {
+	t.Helper()
+
+	contents, err := ioutil.ReadFile(filepath.Join("..", "fixtures", "junit", fixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(dir, filename), contents, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPut(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "put-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	fakeRunner := &runnerfakes.FakeRunner{}
+	fakeRunner.RunStub = func(config runner.Config) error {
+		copyFixtureToDir(t, "success.xml", config.WorkDir, "junit_1.xml")
+		copyFixtureToDir(t, "testsuite-root.xml", config.WorkDir, "junit_2.xml")
+		copyFixtureToDir(t, "failures.xml", config.WorkDir, "not-junit.xml")
+		return nil
+	}
+	uploadedContents := bytes.Buffer{}
+	fakeStorage := &storagefakes.FakeStorage{}
+	fakeStorage.PutStub = func(key string, reader io.Reader) error {
+		_, err := io.Copy(&uploadedContents, reader)
+		return err
+	}
+
+	calls := 0
+	putter := out.Putter{
+		Storage: fakeStorage,
+		Runner:  fakeRunner,
+		Now: func() time.Time {
+			calls++
+			return fixedTime().Add(time.Duration(calls-1) * 90 * time.Second)
+		},
+	}
+
+	result, err := putter.Put(models.OutRequest{
+		SourceDir: tmpDir,
+		Params: models.OutParams{
+			DockerImage: "golang:latest",
+			Command:     "ginkgo -r -p",
+			ResultsType: "junit",
+			ResultsConfig: models.ResultsConfig{
+				Path: "junit_*.xml",
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	helpers.AssertEquals(t, fakeRunner.RunCallCount(), 1)
+	helpers.AssertEquals(t, fakeRunner.RunArgsForCall(0), runner.Config{
+		Image:     "golang:latest",
+		Command:   "ginkgo -r -p",
+		SourceDir: tmpDir,
+		WorkDir:   tmpDir,
+	})
+
+	helpers.AssertEquals(t, fakeStorage.PutCallCount(), 1)
+	key, _ := fakeStorage.PutArgsForCall(0)
+	helpers.AssertEquals(t, key, "test-results-2018-01-02T15:04:05.123Z.xml")
+
+	uploaded, err := junit.Parse(&uploadedContents)
+	if err != nil {
+		t.Fatal(err)
+	}
+	helpers.AssertEquals(t, len(uploaded.TestSuites), 2)
+	helpers.AssertEquals(t, uploaded.TestSuites[0].Name, "github.com/ljfranklin/test-runner-resource/storage")
+	helpers.AssertEquals(t, uploaded.TestSuites[1].Name, "integration")
+	helpers.AssertEquals(t, uploaded.Tests, 34)
+	helpers.AssertEquals(t, uploaded.Failures, 1)
+	helpers.AssertEquals(t, uploaded.Errors, 1)
+	helpers.AssertEquals(t, uploaded.Skipped, 1)
+	helpers.AssertEquals(t, uploaded.Time, 90.0)
+
+	helpers.AssertEquals(t, result.Version, models.Version{
+		Key: "test-results-2018-01-02T15:04:05.123Z.xml",
+	})
+}
+
+func TestPutPrintsSummariesOfCurrentRun(t *testing.T) {
+	sourceDir, err := ioutil.TempDir("", "put-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(sourceDir)
+
+	fakeRunner := &runnerfakes.FakeRunner{}
+	fakeRunner.RunStub = func(config runner.Config) error {
+		copyFixtureToDir(t, "success.xml", config.WorkDir, "junit_1.xml")
+		return nil
+	}
+	fakeJunit := &viewerfakes.FakeJunit{}
+
+	putter := out.Putter{
+		Storage:     &storagefakes.FakeStorage{},
+		Runner:      fakeRunner,
+		JunitViewer: fakeJunit,
+		Now:         fixedTime,
+	}
+
+	_, err = putter.Put(models.OutRequest{
+		SourceDir: sourceDir,
+		Params: models.OutParams{
+			DockerImage: "golang:latest",
+			Command:     "go test",
+			ResultsConfig: models.ResultsConfig{
+				Path: "junit_*.xml",
+			},
+			Summaries: []models.Summary{
+				{
+					Type: "durations",
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	helpers.AssertEquals(t, fakeJunit.PrintSummaryCallCount(), 1)
+	summary, runs := fakeJunit.PrintSummaryArgsForCall(0)
+	helpers.AssertEquals(t, summary, models.Summary{
+		Type: "durations",
+	})
+	helpers.AssertEquals(t, len(runs), 1)
+	helpers.AssertEquals(t, runs[0].Key, "test-results-2018-01-02T15:04:05.123Z.xml")
+	helpers.AssertEquals(t, runs[0].Timestamp, fixedTime())
+	helpers.AssertEquals(t, runs[0].Suites.Totals().Tests > 0, true)
+}
+
+func TestPutWithDisabledStorage(t *testing.T) {
+	sourceDir, err := ioutil.TempDir("", "put-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(sourceDir)
+
+	fakeRunner := &runnerfakes.FakeRunner{}
+	fakeRunner.RunStub = func(config runner.Config) error {
+		copyFixtureToDir(t, "failures.xml", config.WorkDir, "junit_1.xml")
+		return runner.CommandFailed{ExitStatus: 1}
+	}
+	fakeJunit := &viewerfakes.FakeJunit{}
+
+	putter := out.Putter{
+		Storage:     storage.NewDisabled(),
+		Runner:      fakeRunner,
+		JunitViewer: fakeJunit,
+		Now:         fixedTime,
+	}
+
+	result, err := putter.Put(models.OutRequest{
+		SourceDir: sourceDir,
+		Params: models.OutParams{
+			DockerImage: "golang:latest",
+			Command:     "go test",
+			ResultsConfig: models.ResultsConfig{
+				Path: "junit_*.xml",
+			},
+		},
+	})
+	if err == nil {
+		t.Fatal("expected an error on failed command but none occurred")
+	}
+
+	// summaries are printed by default since `get` has no history
+	helpers.AssertEquals(t, fakeJunit.PrintSummaryCallCount(), 1)
+	summary, _ := fakeJunit.PrintSummaryArgsForCall(0)
+	helpers.AssertEquals(t, summary, models.Summary{
+		Type: "pass-fail",
+	})
+	helpers.AssertEquals(t, result.Version, models.Version{
+		Key: "test-results-2018-01-02T15:04:05.123Z.xml",
+	})
+}
+
+func newMultiStorage(t *testing.T, rootDir string) storage.Storage {
+	t.Helper()
+
+	storages := []interface{}{}
+	for _, label := range []string{"team-a", "team-b"} {
+		if err := os.MkdirAll(filepath.Join(rootDir, label), 0755); err != nil {
+			t.Fatal(err)
+		}
+		storages = append(storages, map[string]interface{}{
+			"label":        label,
+			"storage_type": "filesystem",
+			"storage_config": map[string]interface{}{
+				"path": filepath.Join(rootDir, label),
+			},
+		})
+	}
+	multi, err := storage.New("multi", map[string]interface{}{
+		"storages": storages,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return multi
+}
+
+func TestPutWithMultiStorage(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "put-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	storageDir, err := ioutil.TempDir("", "put-storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(storageDir)
+
+	fakeRunner := &runnerfakes.FakeRunner{}
+	fakeRunner.RunStub = func(config runner.Config) error {
+		copyFixtureToDir(t, "success.xml", config.WorkDir, "junit_1.xml")
+		return nil
+	}
+	fakeJunit := &viewerfakes.FakeJunit{}
+
+	putter := out.Putter{
+		Storage:     newMultiStorage(t, storageDir),
+		Runner:      fakeRunner,
+		JunitViewer: fakeJunit,
+		Now:         fixedTime,
+	}
+
+	result, err := putter.Put(models.OutRequest{
+		SourceDir: tmpDir,
+		Params: models.OutParams{
+			DockerImage: "golang:latest",
+			Command:     "ginkgo -r -p",
+			Source:      "team-b",
+			ResultsConfig: models.ResultsConfig{
+				Path: "junit_*.xml",
+			},
+			Summaries: []models.Summary{{Type: "pass-fail"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	helpers.AssertEquals(t, result.Version, models.Version{
+		Key: "team-b/test-results-2018-01-02T15:04:05.123Z.xml",
+	})
+	if _, err = os.Stat(filepath.Join(storageDir, "team-b", "test-results-2018-01-02T15:04:05.123Z.xml")); err != nil {
+		t.Fatalf("expected results to be uploaded to team-b: %s", err)
+	}
+	helpers.AssertEquals(t, fakeJunit.PrintSummaryCallCount(), 1)
+}
+
+func TestPutErrorOnInvalidSource(t *testing.T) {
+	storageDir, err := ioutil.TempDir("", "put-storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(storageDir)
+
+	for _, testCase := range []struct {
+		storage  storage.Storage
+		source   string
+		expected string
+	}{
+		{storage: newMultiStorage(t, storageDir), source: "", expected: "missing required params: source"},
+		{storage: newMultiStorage(t, storageDir), source: "team-c", expected: "'team-a', 'team-b'"},
+		{storage: &storagefakes.FakeStorage{}, source: "team-a", expected: "storage_type 'multi'"},
+	} {
+		fakeRunner := &runnerfakes.FakeRunner{}
+		putter := out.Putter{
+			Storage: testCase.storage,
+			Runner:  fakeRunner,
+		}
+
+		_, err := putter.Put(models.OutRequest{
+			SourceDir: "some-dir",
+			Params: models.OutParams{
+				DockerImage: "golang:latest",
+				Command:     "ginkgo -r -p",
+				Source:      testCase.source,
+				ResultsConfig: models.ResultsConfig{
+					Path: "junit_*.xml",
+				},
+			},
+		})
+		if err == nil {
+			t.Fatalf("expected err for source '%s' but none occurred", testCase.source)
+		}
+		if !strings.Contains(err.Error(), testCase.expected) {
+			t.Fatalf("expected err to contain '%s', but it did not: %s", testCase.expected, err)
+		}
+		// the command is never run when the results cannot be uploaded
+		helpers.AssertEquals(t, fakeRunner.RunCallCount(), 0)
+	}
+}
+
+func TestPutFindsNestedResultsFiles(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "put-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	fakeRunner := &runnerfakes.FakeRunner{}
+	fakeRunner.RunStub = func(config runner.Config) error {
+		nestedDir := filepath.Join(config.WorkDir, "ci-repo", "storage")
+		if err := os.MkdirAll(nestedDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		copyFixtureToDir(t, "success.xml", filepath.Join(config.WorkDir, "ci-repo"), "junit_1.xml")
+		copyFixtureToDir(t, "failures.xml", nestedDir, "junit_1.xml")
+		copyFixtureToDir(t, "testsuite-root.xml", nestedDir, "other.xml")
+		return nil
+	}
+	uploadedContents := bytes.Buffer{}
+	fakeStorage := &storagefakes.FakeStorage{}
+	fakeStorage.PutStub = func(key string, reader io.Reader) error {
+		_, err := io.Copy(&uploadedContents, reader)
+		return err
+	}
+
+	putter := out.Putter{
+		Storage: fakeStorage,
+		Runner:  fakeRunner,
+		Now:     fixedTime,
+	}
+
+	_, err = putter.Put(models.OutRequest{
+		SourceDir: tmpDir,
+		Params: models.OutParams{
+			DockerImage: "golang:latest",
+			Command:     "ginkgo -r -p",
+			ResultsConfig: models.ResultsConfig{
+				Path: "ci-repo/**/junit_*.xml",
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	uploaded, err := junit.Parse(&uploadedContents)
+	if err != nil {
+		t.Fatal(err)
+	}
+	helpers.AssertEquals(t, len(uploaded.TestSuites), 2)
+	helpers.AssertEquals(t, uploaded.Tests, 38)
+	helpers.AssertEquals(t, uploaded.Failures, 8)
+}
+
+func TestPutWithTAPResults(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "put-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	fakeRunner := &runnerfakes.FakeRunner{}
+	fakeRunner.RunStub = func(config runner.Config) error {
+		contents, err := ioutil.ReadFile(filepath.Join("..", "fixtures", "tap", "bats.tap"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = ioutil.WriteFile(filepath.Join(config.WorkDir, "math.tap"), contents, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return runner.CommandFailed{ExitStatus: 1}
+	}
+	uploadedContents := bytes.Buffer{}
+	fakeStorage := &storagefakes.FakeStorage{}
+	fakeStorage.PutStub = func(key string, reader io.Reader) error {
+		_, err := io.Copy(&uploadedContents, reader)
+		return err
+	}
+
+	putter := out.Putter{
+		Storage: fakeStorage,
+		Runner:  fakeRunner,
+		Now:     fixedTime,
+	}
+
+	_, err = putter.Put(models.OutRequest{
+		SourceDir: tmpDir,
+		Params: models.OutParams{
+			DockerImage: "bats/bats:latest",
+			Command:     "bats --tap test/ > math.tap",
+			ResultsType: "tap",
+			ResultsConfig: models.ResultsConfig{
+				Path: "*.tap",
+			},
+		},
+	})
+	if err == nil {
+		t.Fatal("expected err to occur but it did not")
+	}
+
+	uploaded, err := junit.Parse(&uploadedContents)
+	if err != nil {
+		t.Fatal(err)
+	}
+	helpers.AssertEquals(t, len(uploaded.TestSuites), 1)
+	helpers.AssertEquals(t, uploaded.TestSuites[0].Name, "math")
+	helpers.AssertEquals(t, uploaded.Tests, 4)
+	helpers.AssertEquals(t, uploaded.Failures, 1)
+	helpers.AssertEquals(t, uploaded.Skipped, 1)
+}
+
+func TestPutWithGoTestJSONResults(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "put-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	fakeRunner := &runnerfakes.FakeRunner{}
+	fakeRunner.RunStub = func(config runner.Config) error {
+		contents, err := ioutil.ReadFile(filepath.Join("..", "fixtures", "gotest", "parallel.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = ioutil.WriteFile(filepath.Join(config.WorkDir, "results.json"), contents, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return runner.CommandFailed{ExitStatus: 1}
+	}
+	uploadedContents := bytes.Buffer{}
+	fakeStorage := &storagefakes.FakeStorage{}
+	fakeStorage.PutStub = func(key string, reader io.Reader) error {
+		_, err := io.Copy(&uploadedContents, reader)
+		return err
+	}
+
+	putter := out.Putter{
+		Storage: fakeStorage,
+		Runner:  fakeRunner,
+		Now:     fixedTime,
+	}
+
+	_, err = putter.Put(models.OutRequest{
+		SourceDir: tmpDir,
+		Params: models.OutParams{
+			DockerImage: "golang:latest",
+			Command:     "go test -json ./... > results.json",
+			ResultsType: "gotest-json",
+			ResultsConfig: models.ResultsConfig{
+				Path: "results.json",
+			},
+		},
+	})
+	if err == nil {
+		t.Fatal("expected err to occur but it did not")
+	}
+
+	uploaded, err := junit.Parse(&uploadedContents)
+	if err != nil {
+		t.Fatal(err)
+	}
+	helpers.AssertEquals(t, len(uploaded.TestSuites), 1)
+	helpers.AssertEquals(t, uploaded.TestSuites[0].Name, "example.com/calc")
+	helpers.AssertEquals(t, uploaded.Tests, 5)
+	helpers.AssertEquals(t, uploaded.Failures, 2)
+	helpers.AssertEquals(t, uploaded.Skipped, 1)
+}
+
+func TestPutUploadsResultsWhenCommandFails(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "put-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	fakeRunner := &runnerfakes.FakeRunner{}
+	fakeRunner.RunStub = func(config runner.Config) error {
+		copyFixtureToDir(t, "failures.xml", config.WorkDir, "junit_1.xml")
+		return runner.CommandFailed{ExitStatus: 1}
+	}
+	fakeStorage := &storagefakes.FakeStorage{}
+
+	putter := out.Putter{
+		Storage: fakeStorage,
+		Runner:  fakeRunner,
+		Now:     fixedTime,
+	}
+
+	result, err := putter.Put(models.OutRequest{
+		SourceDir: tmpDir,
+		Params: models.OutParams{
+			DockerImage: "golang:latest",
+			Command:     "ginkgo -r -p",
+			ResultsConfig: models.ResultsConfig{
+				Path: "junit_*.xml",
+			},
+		},
+	})
+	if err == nil {
+		t.Fatal("expected err to occur but it did not")
+	}
+	if !strings.Contains(err.Error(), "status 1") {
+		t.Fatalf("expected err to contain 'status 1', but it did not: %s", err)
+	}
+
+	helpers.AssertEquals(t, fakeStorage.PutCallCount(), 1)
+	helpers.AssertEquals(t, result.Version, models.Version{
+		Key: "test-results-2018-01-02T15:04:05.123Z.xml",
+	})
+}
+
+func TestPutWithLocalRunner(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "put-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	fakeRunner := &runnerfakes.FakeRunner{}
+	fakeRunner.RunStub = func(config runner.Config) error {
+		if err := os.MkdirAll(config.WorkDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		copyFixtureToDir(t, "failures.xml", config.WorkDir, "junit_1.xml")
+		return runner.CommandFailed{ExitStatus: -1, Timeout: 30 * time.Minute}
+	}
+	fakeStorage := &storagefakes.FakeStorage{}
+
+	putter := out.Putter{
+		Storage: fakeStorage,
+		Runner:  fakeRunner,
+		Now:     fixedTime,
+	}
+
+	_, err = putter.Put(models.OutRequest{
+		SourceDir: tmpDir,
+		Params: models.OutParams{
+			Runner:  "local",
+			Command: "go test ./...",
+			Shell:   "bash",
+			WorkDir: "ci-repo",
+			Env: map[string]string{
+				"SOME_VAR": "some-value",
+			},
+			Timeout: "30m",
+			ResultsConfig: models.ResultsConfig{
+				Path: "ci-repo/junit_*.xml",
+			},
+		},
+	})
+	if err == nil {
+		t.Fatal("expected err to occur but it did not")
+	}
+	if !strings.Contains(err.Error(), "timed out after 30m") {
+		t.Fatalf("expected err to contain 'timed out after 30m', but it did not: %s", err)
+	}
+
+	helpers.AssertEquals(t, fakeRunner.RunArgsForCall(0), runner.Config{
+		Command:   "go test ./...",
+		SourceDir: tmpDir,
+		WorkDir:   filepath.Join(tmpDir, "ci-repo"),
+		Shell:     "bash",
+		Env: map[string]string{
+			"SOME_VAR": "some-value",
+		},
+		Timeout: 30 * time.Minute,
+	})
+	helpers.AssertEquals(t, fakeStorage.PutCallCount(), 1)
+}
+
+func TestPutWithDotPrefixedWorkDir(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "put-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	fakeRunner := &runnerfakes.FakeRunner{}
+	fakeRunner.RunStub = func(config runner.Config) error {
+		if err := os.MkdirAll(config.WorkDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		copyFixtureToDir(t, "success.xml", config.WorkDir, "junit_1.xml")
+		return nil
+	}
+
+	putter := out.Putter{
+		Storage: &storagefakes.FakeStorage{},
+		Runner:  fakeRunner,
+		Now:     fixedTime,
+	}
+
+	_, err = putter.Put(models.OutRequest{
+		SourceDir: tmpDir,
+		Params: models.OutParams{
+			Runner:  "local",
+			Command: "go test ./...",
+			WorkDir: "..cache",
+			ResultsConfig: models.ResultsConfig{
+				Path: "..cache/junit_*.xml",
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := fakeRunner.RunArgsForCall(0)
+	helpers.AssertEquals(t, config.WorkDir, filepath.Join(tmpDir, "..cache"))
+}
+
+func TestPutErrorOnInvalidRunnerParams(t *testing.T) {
+	resultsConfig := models.ResultsConfig{
+		Path: "junit_*.xml",
+	}
+	for _, testCase := range []struct {
+		params   models.OutParams
+		expected string
+	}{
+		{
+			params:   models.OutParams{Runner: "some-runner"},
+			expected: "some-runner",
+		},
+		{
+			params:   models.OutParams{Runner: "local"},
+			expected: "missing required params: command, results_config.path",
+		},
+		{
+			params:   models.OutParams{DockerImage: "golang:latest", Command: "go test", ResultsConfig: resultsConfig, Timeout: "30m"},
+			expected: "only supported with runner 'local'",
+		},
+		{
+			params:   models.OutParams{Runner: "local", Command: "go test", ResultsConfig: resultsConfig, Timeout: "forever"},
+			expected: "invalid timeout 'forever'",
+		},
+		{
+			params:   models.OutParams{Runner: "local", Command: "go test", ResultsConfig: resultsConfig, WorkDir: "../outside"},
+			expected: "workdir '../outside'",
+		},
+		{
+			params:   models.OutParams{Runner: "local", Command: "go test", ResultsConfig: resultsConfig, WorkDir: "ci-repo/../.."},
+			expected: "workdir 'ci-repo/../..'",
+		},
+		{
+			params:   models.OutParams{DockerImage: "golang:latest", DockerImagePath: "ci-image/image", Command: "go test", ResultsConfig: resultsConfig},
+			expected: "mutually exclusive",
+		},
+		{
+			params:   models.OutParams{Runner: "local", DockerImagePath: "ci-image/image", Command: "go test", ResultsConfig: resultsConfig},
+			expected: "only supported with runner 'docker'",
+		},
+	} {
+		fakeRunner := &runnerfakes.FakeRunner{}
+		putter := out.Putter{
+			Storage: &storagefakes.FakeStorage{},
+			Runner:  fakeRunner,
+		}
+
+		_, err := putter.Put(models.OutRequest{
+			SourceDir: "some-dir",
+			Params:    testCase.params,
+		})
+		if err == nil {
+			t.Fatalf("expected err for params %+v but none occurred", testCase.params)
+		}
+		if !strings.Contains(err.Error(), testCase.expected) {
+			t.Fatalf("expected err to contain '%s', but it did not: %s", testCase.expected, err)
+		}
+		helpers.AssertEquals(t, fakeRunner.RunCallCount(), 0)
+	}
+}
+
+func TestPutErrorOnMissingParams(t *testing.T) {
+	fakeRunner := &runnerfakes.FakeRunner{}
+	fakeStorage := &storagefakes.FakeStorage{}
+
+	putter := out.Putter{
+		Storage: fakeStorage,
+		Runner:  fakeRunner,
+	}
+
+	_, err := putter.Put(models.OutRequest{
+		SourceDir: "some-dir",
+		Params:    models.OutParams{},
+	})
+	if err == nil {
+		t.Fatal("expected err to occur but it did not")
+	}
+	for _, param := range []string{"docker_image", "command", "results_config.path"} {
+		if !strings.Contains(err.Error(), param) {
+			t.Fatalf("expected err to contain '%s', but it did not: %s", param, err)
+		}
+	}
+	helpers.AssertEquals(t, fakeRunner.RunCallCount(), 0)
+}
+
+func TestPutErrorOnInvalidResultsType(t *testing.T) {
+	fakeRunner := &runnerfakes.FakeRunner{}
+	fakeStorage := &storagefakes.FakeStorage{}
+
+	putter := out.Putter{
+		Storage: fakeStorage,
+		Runner:  fakeRunner,
+	}
+
+	_, err := putter.Put(models.OutRequest{
+		SourceDir: "some-dir",
+		Params: models.OutParams{
+			DockerImage: "golang:latest",
+			Command:     "ginkgo -r -p",
+			ResultsType: "some-invalid-type",
+			ResultsConfig: models.ResultsConfig{
+				Path: "junit_*.xml",
+			},
+		},
+	})
+	if err == nil {
+		t.Fatal("expected err to occur but it did not")
+	}
+	if !strings.Contains(err.Error(), "some-invalid-type") {
+		t.Fatalf("expected err to contain 'some-invalid-type', but it did not: %s", err)
+	}
+	if !strings.Contains(err.Error(), "'gotest-json', 'junit', 'tap'") {
+		t.Fatalf("expected err to list the supported types, but it did not: %s", err)
+	}
+}
+
+func TestPutErrorOnRunFailure(t *testing.T) {
+	fakeRunner := &runnerfakes.FakeRunner{}
+	fakeRunner.RunReturns(errors.New("some-error"))
+	fakeStorage := &storagefakes.FakeStorage{}
+
+	putter := out.Putter{
+		Storage: fakeStorage,
+		Runner:  fakeRunner,
+	}
+
+	_, err := putter.Put(models.OutRequest{
+		SourceDir: "some-dir",
+		Params: models.OutParams{
+			DockerImage: "golang:latest",
+			Command:     "ginkgo -r -p",
+			ResultsConfig: models.ResultsConfig{
+				Path: "junit_*.xml",
+			},
+		},
+	})
+	if err == nil {
+		t.Fatal("expected err to occur but it did not")
+	}
+	if !strings.Contains(err.Error(), "some-error") {
+		t.Fatalf("expected err to contain 'some-error', but it did not: %s", err)
+	}
+	helpers.AssertEquals(t, fakeStorage.PutCallCount(), 0)
+}
+
+func TestPutErrorOnMissingResults(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "put-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	fakeRunner := &runnerfakes.FakeRunner{}
+	fakeStorage := &storagefakes.FakeStorage{}
+
+	putter := out.Putter{
+		Storage: fakeStorage,
+		Runner:  fakeRunner,
+	}
+
+	_, err = putter.Put(models.OutRequest{
+		SourceDir: tmpDir,
+		Params: models.OutParams{
+			DockerImage: "golang:latest",
+			Command:     "ginkgo -r -p",
+			ResultsConfig: models.ResultsConfig{
+				Path: "junit_*.xml",
+			},
+		},
+	})
+	if err == nil {
+		t.Fatal("expected err to occur but it did not")
+	}
+	if !strings.Contains(err.Error(), "junit_*.xml") {
+		t.Fatalf("expected err to contain 'junit_*.xml', but it did not: %s", err)
+	}
+	helpers.AssertEquals(t, fakeStorage.PutCallCount(), 0)
+}
+
+func TestPutErrorOnStorageFailure(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "put-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	fakeRunner := &runnerfakes.FakeRunner{}
+	fakeRunner.RunStub = func(config runner.Config) error {
+		copyFixtureToDir(t, "success.xml", config.WorkDir, "junit_1.xml")
+		return nil
+	}
+	fakeStorage := &storagefakes.FakeStorage{}
+	fakeStorage.PutReturns(errors.New("some-error"))
+
+	putter := out.Putter{
+		Storage: fakeStorage,
+		Runner:  fakeRunner,
+	}
+
+	_, err = putter.Put(models.OutRequest{
+		SourceDir: tmpDir,
+		Params: models.OutParams{
+			DockerImage: "golang:latest",
+			Command:     "ginkgo -r -p",
+			ResultsConfig: models.ResultsConfig{
+				Path: "junit_*.xml",
+			},
+		},
+	})
+	if err == nil {
+		t.Fatal("expected err to occur but it did not")
+	}
+	if !strings.Contains(err.Error(), "some-error") {
+		t.Fatalf("expected err to contain 'some-error', but it did not: %s", err)
+	}
+}
+
+func TestPutRecordsMetadata(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "put-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	fakeRunner := &runnerfakes.FakeRunner{}
+	fakeRunner.RunStub = func(config runner.Config) error {
+		copyFixtureToDir(t, "success.xml", config.WorkDir, "junit_1.xml")
+		return nil
+	}
+	uploadedContents := bytes.Buffer{}
+	fakeStorage := &storagefakes.FakeStorage{}
+	fakeStorage.PutStub = func(key string, reader io.Reader) error {
+		_, err := io.Copy(&uploadedContents, reader)
+		return err
+	}
+
+	env := map[string]string{
+		"BUILD_ID":            "1234",
+		"BUILD_NAME":          "42",
+		"BUILD_JOB_NAME":      "integration",
+		"BUILD_PIPELINE_NAME": "main",
+		"BUILD_TEAM_NAME":     "some-team",
+		"ATC_EXTERNAL_URL":    "https://ci.example.com/",
+	}
+	putter := out.Putter{
+		Storage: fakeStorage,
+		Runner:  fakeRunner,
+		Now:     fixedTime,
+		Getenv: func(name string) string {
+			return env[name]
+		},
+	}
+
+	_, err = putter.Put(models.OutRequest{
+		SourceDir: tmpDir,
+		Params: models.OutParams{
+			DockerImage: "golang:latest",
+			Command:     "ginkgo -r -p",
+			ResultsConfig: models.ResultsConfig{
+				Path: "junit_*.xml",
+			},
+			Metadata: map[string]string{
+				"iaas":       "azure",
+				"build_name": "overridden",
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	uploaded, err := junit.Parse(&uploadedContents)
+	if err != nil {
+		t.Fatal(err)
+	}
+	helpers.AssertEquals(t, uploaded.Metadata(), map[string]string{
+		"iaas":                "azure",
+		"build_id":            "1234",
+		"build_name":          "overridden",
+		"build_job_name":      "integration",
+		"build_pipeline_name": "main",
+		"build_team_name":     "some-team",
+		"atc_external_url":    "https://ci.example.com/",
+		"build_url":           "https://ci.example.com/teams/some-team/pipelines/main/jobs/integration/builds/42",
+	})
+}
+
+func TestPutWithDockerImagePath(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "put-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// the rootfs format of the registry-image resource
+	if err = os.MkdirAll(filepath.Join(tmpDir, "ci-image", "rootfs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, contents := range map[string]string{
+		"repository": "golang",
+		"tag":        "1.10",
+		"digest":     "sha256:some-digest",
+	} {
+		if err = ioutil.WriteFile(filepath.Join(tmpDir, "ci-image", name), []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fakeRunner := &runnerfakes.FakeRunner{}
+	fakeRunner.RunStub = func(config runner.Config) error {
+		copyFixtureToDir(t, "success.xml", config.WorkDir, "junit_1.xml")
+		return nil
+	}
+	uploadedContents := bytes.Buffer{}
+	fakeStorage := &storagefakes.FakeStorage{}
+	fakeStorage.PutStub = func(key string, reader io.Reader) error {
+		_, err := io.Copy(&uploadedContents, reader)
+		return err
+	}
+
+	putter := out.Putter{
+		Storage: fakeStorage,
+		Runner:  fakeRunner,
+		Now:     fixedTime,
+		Getenv: func(string) string {
+			return ""
+		},
+	}
+
+	_, err = putter.Put(models.OutRequest{
+		SourceDir: tmpDir,
+		Params: models.OutParams{
+			DockerImagePath: "ci-image",
+			Command:         "ginkgo -r -p",
+			ResultsConfig: models.ResultsConfig{
+				Path: "junit_*.xml",
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	helpers.AssertEquals(t, fakeRunner.RunArgsForCall(0), runner.Config{
+		SavedImage: &runner.SavedImage{
+			RootfsPath: filepath.Join(tmpDir, "ci-image", "rootfs"),
+			Tag:        "golang:1.10",
+			Digest:     "sha256:some-digest",
+		},
+		Command:   "ginkgo -r -p",
+		SourceDir: tmpDir,
+		WorkDir:   tmpDir,
+	})
+
+	uploaded, err := junit.Parse(&uploadedContents)
+	if err != nil {
+		t.Fatal(err)
+	}
+	helpers.AssertEquals(t, uploaded.Metadata(), map[string]string{
+		"docker_image":        "golang:1.10",
+		"docker_image_digest": "sha256:some-digest",
+	})
+}
+
+func TestPutErrorOnInvalidDockerImagePath(t *testing.T) {
+	fakeRunner := &runnerfakes.FakeRunner{}
+	putter := out.Putter{
+		Storage: &storagefakes.FakeStorage{},
+		Runner:  fakeRunner,
+	}
+
+	_, err := putter.Put(models.OutRequest{
+		SourceDir: "some-dir",
+		Params: models.OutParams{
+			DockerImagePath: "missing-image",
+			Command:         "ginkgo -r -p",
+			ResultsConfig: models.ResultsConfig{
+				Path: "junit_*.xml",
+			},
+		},
+	})
+	if err == nil {
+		t.Fatal("expected err to occur but it did not")
+	}
+	if !strings.Contains(err.Error(), "missing-image") {
+		t.Fatalf("expected err to contain 'missing-image', but it did not: %s", err)
+	}
+	helpers.AssertEquals(t, fakeRunner.RunCallCount(), 0)
+}
@@ -0,0 +1,137 @@
+package out
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ljfranklin/test-runner-resource/gotest"
+	"github.com/ljfranklin/test-runner-resource/junit"
+	"github.com/ljfranklin/test-runner-resource/tap"
+)
+
+// resultsParsers converts each supported results_type into the JUnit
+// model which is uploaded and summarized.
+var resultsParsers = map[string]func(string) (junit.TestSuites, error){
+	"gotest-json": gotest.ParseFile,
+	"junit":       junit.ParseFile,
+	"tap":         tap.ParseFile,
+}
+
+func resultsTypes() []string {
+	types := []string{}
+	for resultsType := range resultsParsers {
+		types = append(types, resultsType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// ParseResultsFile parses a file of any supported results_type,
+// defaulting to "junit".
+func ParseResultsFile(path string, resultsType string) (junit.TestSuites, error) {
+	if resultsType == "" {
+		resultsType = defaultResultsType
+	}
+	parse, ok := resultsParsers[resultsType]
+	if !ok {
+		return junit.TestSuites{}, unrecognizedResultsType(resultsType)
+	}
+	return parse(path)
+}
+
+func unrecognizedResultsType(resultsType string) error {
+	return fmt.Errorf("unrecognized results_type '%s'; set results_type to one of the following: '%s'", resultsType, strings.Join(resultsTypes(), "', '"))
+}
+
+func collectResults(sourceDir string, pattern string, resultsType string) (junit.TestSuites, error) {
+
+	resultsFiles, err := findResultsFiles(sourceDir, pattern)
+	if err != nil {
+		return junit.TestSuites{}, err
+	}
+
+	documents := []junit.TestSuites{}
+	for _, resultsFile := range resultsFiles {
+		document, err := ParseResultsFile(resultsFile, resultsType)
+		if err != nil {
+			return junit.TestSuites{}, err
+		}
+		documents = append(documents, document)
+	}
+
+	return junit.Merge(documents), nil
+}
+
+// findResultsFiles supports the standard filepath.Match syntax
+// plus `**` to match any number of nested directories.
+func findResultsFiles(sourceDir string, pattern string) ([]string, error) {
+	var matches []string
+	var err error
+	if strings.Contains(pattern, "**") {
+		matches, err = globRecursive(sourceDir, pattern)
+	} else {
+		matches, err = filepath.Glob(filepath.Join(sourceDir, pattern))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to glob for results files: %s", err)
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("found no results files matching '%s' in '%s'", pattern, sourceDir)
+	}
+	sort.Strings(matches)
+
+	return matches, nil
+}
+
+func globRecursive(sourceDir string, pattern string) ([]string, error) {
+	patternParts := strings.Split(filepath.ToSlash(pattern), "/")
+	for _, part := range patternParts {
+		if _, err := filepath.Match(part, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	matches := []string{}
+	err := filepath.Walk(sourceDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		relPath, err := filepath.Rel(sourceDir, path)
+		if err != nil {
+			return err
+		}
+		if matchParts(patternParts, strings.Split(filepath.ToSlash(relPath), "/")) {
+			matches = append(matches, path)
+		}
+		return nil
+	})
+
+	return matches, err
+}
+
+func matchParts(patternParts []string, pathParts []string) bool {
+	if len(patternParts) == 0 {
+		return len(pathParts) == 0
+	}
+	if patternParts[0] == "**" {
+		for i := 0; i <= len(pathParts); i++ {
+			if matchParts(patternParts[1:], pathParts[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(pathParts) == 0 {
+		return false
+	}
+	if matched, _ := filepath.Match(patternParts[0], pathParts[0]); !matched {
+		return false
+	}
+	return matchParts(patternParts[1:], pathParts[1:])
+}
@@ -0,0 +1,175 @@
+package runner
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+type Docker struct {
+	OutputWriter io.Writer
+	// defaults to the docker binary on $PATH
+	DockerPath string
+	// Optional scripts to start and stop a docker-in-docker daemon
+	StartDaemonScript string
+	StopDaemonScript  string
+}
+
+func (d Docker) Run(config Config) error {
+	if d.StartDaemonScript != "" {
+		if err := d.runScript(d.StartDaemonScript); err != nil {
+			return fmt.Errorf("failed to start docker daemon: %s", err)
+		}
+		if d.StopDaemonScript != "" {
+			defer d.runScript(d.StopDaemonScript)
+		}
+	}
+
+	image := config.Image
+	imageArgs := []string{}
+	if config.SavedImage != nil {
+		var err error
+		if image, err = d.loadImage(*config.SavedImage); err != nil {
+			return fmt.Errorf("failed to load image from '%s': %s", config.SavedImage.path(), err)
+		}
+		// config.Env is added afterwards so it takes precedence
+		for _, envVar := range config.SavedImage.Env {
+			imageArgs = append(imageArgs, "--env", envVar)
+		}
+		if config.SavedImage.User != "" {
+			imageArgs = append(imageArgs, "--user", config.SavedImage.User)
+		}
+	}
+
+	sourceDir := config.SourceDir
+	if sourceDir == "" {
+		sourceDir = config.WorkDir
+	}
+	args := []string{
+		"run", "--rm",
+		"--volume", fmt.Sprintf("%s:%s", sourceDir, sourceDir),
+		"--workdir", config.WorkDir,
+	}
+	args = append(args, imageArgs...)
+	for _, envVar := range config.envVars() {
+		args = append(args, "--env", envVar)
+	}
+	args = append(args, image)
+	args = append(args, config.shellArgs()...)
+
+	cmd := exec.Command(d.dockerPath(), args...)
+	cmd.Stdout = d.OutputWriter
+	cmd.Stderr = d.OutputWriter
+
+	err := cmd.Run()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			// docker itself exits 125 when the container fails to start
+			if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.ExitStatus() != 125 {
+				return CommandFailed{
+					ExitStatus: status.ExitStatus(),
+				}
+			}
+		}
+		return fmt.Errorf("failed to run command in image '%s': %s", image, err)
+	}
+	return nil
+}
+
+// loadImage loads a saved image into the daemon and returns a
+// reference to run it by.
+func (d Docker) loadImage(savedImage SavedImage) (string, error) {
+	var err error
+	output := bytes.Buffer{}
+	if savedImage.TarballPath != "" {
+		cmd := exec.Command(d.dockerPath(), "load", "--input", savedImage.TarballPath)
+		cmd.Stdout = &output
+		cmd.Stderr = d.OutputWriter
+		if err = cmd.Run(); err != nil {
+			return "", err
+		}
+	} else if err = d.importRootfs(savedImage.RootfsPath, &output); err != nil {
+		return "", err
+	}
+
+	image := parseLoadedImage(output.String())
+	if image == "" {
+		return "", fmt.Errorf("unable to determine loaded image from output: %s", output.String())
+	}
+	return image, nil
+}
+
+// importRootfs pipes a tarball of rootfsPath into `docker import`, which
+// prints the ID of the new image.
+func (d Docker) importRootfs(rootfsPath string, output io.Writer) error {
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+
+	tarCmd := exec.Command("tar", "-C", rootfsPath, "-cf", "-", ".")
+	tarCmd.Stdout = writer
+	tarCmd.Stderr = d.OutputWriter
+	importCmd := exec.Command(d.dockerPath(), "import", "-")
+	importCmd.Stdin = reader
+	importCmd.Stdout = output
+	importCmd.Stderr = d.OutputWriter
+
+	tarErr := tarCmd.Start()
+	importErr := importCmd.Start()
+	// the children hold their own copies of the pipe
+	reader.Close()
+	writer.Close()
+	if tarErr == nil {
+		tarErr = tarCmd.Wait()
+	}
+	if importErr == nil {
+		importErr = importCmd.Wait()
+	}
+
+	if importErr != nil {
+		return importErr
+	}
+	if tarErr != nil {
+		return fmt.Errorf("failed to archive rootfs: %s", tarErr)
+	}
+	return nil
+}
+
+// parseLoadedImage returns the last image named in the output of
+// `docker load`, e.g. "Loaded image: golang:1.10", or the image ID
+// printed by `docker import`.
+func parseLoadedImage(output string) string {
+	image := ""
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "Loaded image ID: "):
+			image = strings.TrimPrefix(line, "Loaded image ID: ")
+		case strings.HasPrefix(line, "Loaded image: "):
+			image = strings.TrimPrefix(line, "Loaded image: ")
+		case strings.HasPrefix(line, "sha256:"):
+			image = line
+		}
+	}
+	return image
+}
+
+func (d Docker) dockerPath() string {
+	if d.DockerPath == "" {
+		return "docker"
+	}
+	return d.DockerPath
+}
+
+func (d Docker) runScript(path string) error {
+	cmd := exec.Command("bash", path)
+	cmd.Stdout = d.OutputWriter
+	cmd.Stderr = d.OutputWriter
+
+	return cmd.Run()
+}
@@ -0,0 +1,233 @@
+package runner_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ljfranklin/test-runner-resource/runner"
+	"github.com/ljfranklin/test-runner-resource/test/helpers"
+)
+
+// fakeDocker writes a script which records the args of each call and
+// prints output in response to `docker load` and `docker import`.
+func fakeDocker(t *testing.T, dir string, output string) string {
+	t.Helper()
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "output"), []byte(output), 0644); err != nil {
+		t.Fatal(err)
+	}
+	script := fmt.Sprintf(`#!/bin/sh
+printf '%%s\n' "$@" >> %[1]s/calls
+echo --- >> %[1]s/calls
+case "$1" in
+  load|import) cat > /dev/null; cat %[1]s/output ;;
+esac
+`, dir)
+	scriptPath := filepath.Join(dir, "docker")
+	if err := ioutil.WriteFile(scriptPath, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return scriptPath
+}
+
+// dockerCalls returns the args of each call to the fake docker.
+func dockerCalls(t *testing.T, dir string) [][]string {
+	t.Helper()
+
+	contents, err := ioutil.ReadFile(filepath.Join(dir, "calls"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls := [][]string{}
+	for _, call := range strings.Split(strings.TrimSuffix(string(contents), "---\n"), "---\n") {
+		calls = append(calls, strings.Split(strings.TrimSuffix(call, "\n"), "\n"))
+	}
+	return calls
+}
+
+func TestDockerRunMountsSourceDir(t *testing.T) {
+	t.Parallel()
+
+	tmpDir, err := ioutil.TempDir("", "docker-runner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	docker := runner.Docker{
+		OutputWriter: ioutil.Discard,
+		DockerPath:   fakeDocker(t, tmpDir, ""),
+	}
+	err = docker.Run(runner.Config{
+		Image:     "golang:latest",
+		Command:   "go test -json ./... > ../results.json",
+		SourceDir: "/tmp/build/put",
+		WorkDir:   "/tmp/build/put/ci-repo",
+		Shell:     "bash",
+		Env: map[string]string{
+			"GOFLAGS": "-mod=vendor",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	helpers.AssertEquals(t, dockerCalls(t, tmpDir), [][]string{
+		{
+			"run", "--rm",
+			"--volume", "/tmp/build/put:/tmp/build/put",
+			"--workdir", "/tmp/build/put/ci-repo",
+			"--env", "GOFLAGS=-mod=vendor",
+			"golang:latest",
+			"bash", "-c", "go test -json ./... > ../results.json",
+		},
+	})
+}
+
+func TestDockerRunAppliesSavedImageConfig(t *testing.T) {
+	t.Parallel()
+
+	tmpDir, err := ioutil.TempDir("", "docker-runner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	rootfs := filepath.Join(tmpDir, "rootfs")
+	if err = os.MkdirAll(rootfs, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	docker := runner.Docker{
+		OutputWriter: ioutil.Discard,
+		DockerPath:   fakeDocker(t, tmpDir, "sha256:"+imageID+"\n"),
+	}
+	err = docker.Run(runner.Config{
+		SavedImage: &runner.SavedImage{
+			RootfsPath: rootfs,
+			Env:        []string{"PATH=/usr/local/go/bin:/usr/bin", "GOPATH=/go"},
+			User:       "nobody",
+		},
+		Command: "go test ./...",
+		WorkDir: "/tmp/build/put",
+		Env: map[string]string{
+			"GOPATH": "/tmp/build/put/go",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	calls := dockerCalls(t, tmpDir)
+	helpers.AssertEquals(t, len(calls), 2)
+	helpers.AssertEquals(t, calls[1], []string{
+		"run", "--rm",
+		"--volume", "/tmp/build/put:/tmp/build/put",
+		"--workdir", "/tmp/build/put",
+		"--env", "PATH=/usr/local/go/bin:/usr/bin",
+		"--env", "GOPATH=/go",
+		"--user", "nobody",
+		"--env", "GOPATH=/tmp/build/put/go",
+		"sha256:" + imageID,
+		"sh", "-c", "go test ./...",
+	})
+}
+
+func TestDockerRunDefaultsSourceDirToWorkDir(t *testing.T) {
+	t.Parallel()
+
+	tmpDir, err := ioutil.TempDir("", "docker-runner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	docker := runner.Docker{
+		OutputWriter: ioutil.Discard,
+		DockerPath:   fakeDocker(t, tmpDir, ""),
+	}
+	err = docker.Run(runner.Config{
+		Image:   "golang:latest",
+		Command: "go test ./...",
+		WorkDir: "/tmp/build/put",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	helpers.AssertEquals(t, dockerCalls(t, tmpDir), [][]string{
+		{
+			"run", "--rm",
+			"--volume", "/tmp/build/put:/tmp/build/put",
+			"--workdir", "/tmp/build/put",
+			"golang:latest",
+			"sh", "-c", "go test ./...",
+		},
+	})
+}
+
+func TestDockerRunLoadsSavedImage(t *testing.T) {
+	t.Parallel()
+
+	for _, testCase := range []struct {
+		name          string
+		rootfs        bool
+		output        string
+		expectedImage string
+		expectedErr   string
+	}{
+		{name: "tagged tarball", output: "Loaded image: golang:1.10\n", expectedImage: "golang:1.10"},
+		{name: "untagged tarball", output: "Loaded image ID: sha256:" + imageID + "\n", expectedImage: "sha256:" + imageID},
+		{name: "multiple images", output: "Loaded image: golang:1.10\nLoaded image: golang:latest\n", expectedImage: "golang:latest"},
+		{name: "rootfs", rootfs: true, output: "sha256:" + imageID + "\n", expectedImage: "sha256:" + imageID},
+		{name: "unrecognized output", output: "some-unexpected-output\n", expectedErr: "unable to determine loaded image"},
+	} {
+		tmpDir, err := ioutil.TempDir("", "docker-runner")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(tmpDir)
+
+		savedImage := &runner.SavedImage{TarballPath: filepath.Join(tmpDir, "image")}
+		expectedLoad := []string{"load", "--input", savedImage.TarballPath}
+		if testCase.rootfs {
+			savedImage = &runner.SavedImage{RootfsPath: filepath.Join(tmpDir, "rootfs")}
+			if err = os.MkdirAll(savedImage.RootfsPath, 0755); err != nil {
+				t.Fatal(err)
+			}
+			expectedLoad = []string{"import", "-"}
+		}
+
+		docker := runner.Docker{
+			OutputWriter: ioutil.Discard,
+			DockerPath:   fakeDocker(t, tmpDir, testCase.output),
+		}
+		err = docker.Run(runner.Config{
+			SavedImage: savedImage,
+			Command:    "go test ./...",
+			WorkDir:    "/tmp/build/put",
+		})
+
+		if testCase.expectedErr != "" {
+			if err == nil {
+				t.Fatalf("%s: expected err to occur but it did not", testCase.name)
+			}
+			if !strings.Contains(err.Error(), testCase.expectedErr) {
+				t.Fatalf("%s: expected err to contain '%s', but it did not: %s", testCase.name, testCase.expectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %s", testCase.name, err)
+		}
+
+		calls := dockerCalls(t, tmpDir)
+		helpers.AssertEquals(t, len(calls), 2)
+		helpers.AssertEquals(t, calls[0], expectedLoad)
+		helpers.AssertEquals(t, calls[1][len(calls[1])-4], testCase.expectedImage)
+	}
+}
@@ -0,0 +1,194 @@
+package runner
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// SavedImage is an image written to disk by a `get` step, either a
+// tarball from `docker save` or the rootfs format of the
+// registry-image resource.
+type SavedImage struct {
+	// set for tarballs, which are loaded with `docker load`
+	TarballPath string
+	// set for rootfs directories, which are loaded with `docker import`
+	RootfsPath string
+	// e.g. "golang:1.10", empty if the image is untagged
+	Tag string
+	// the registry digest if known, otherwise the image ID
+	Digest string
+	// read from the metadata.json written next to a rootfs, which
+	// `docker import` would otherwise drop, e.g. "PATH=/usr/local/go/bin:..."
+	Env  []string
+	User string
+}
+
+func (s SavedImage) path() string {
+	if s.TarballPath != "" {
+		return s.TarballPath
+	}
+	return s.RootfsPath
+}
+
+type rootfsMetadata struct {
+	Env  []string `json:"env"`
+	User string   `json:"user"`
+}
+
+type dockerManifest struct {
+	Config   string   `json:"Config"`
+	RepoTags []string `json:"RepoTags"`
+}
+
+type ociIndex struct {
+	Manifests []struct {
+		Digest      string            `json:"digest"`
+		Annotations map[string]string `json:"annotations"`
+	} `json:"manifests"`
+}
+
+// LocateSavedImage accepts a tarball, a directory containing an
+// `image` or `image.tar` tarball, or a directory containing `rootfs/`.
+// The tag and digest are read from the metadata files written next to
+// the image by the docker-image and registry-image resources, falling
+// back to the manifest inside the tarball.
+func LocateSavedImage(imagePath string) (SavedImage, error) {
+	info, err := os.Stat(imagePath)
+	if err != nil {
+		return SavedImage{}, fmt.Errorf("failed to find image at '%s': %s", imagePath, err)
+	}
+
+	image := SavedImage{}
+	metadataDir := filepath.Dir(imagePath)
+	if info.IsDir() {
+		metadataDir = imagePath
+		for _, name := range []string{"image", "image.tar"} {
+			candidate := filepath.Join(imagePath, name)
+			if candidateInfo, err := os.Stat(candidate); err == nil && !candidateInfo.IsDir() {
+				image.TarballPath = candidate
+				break
+			}
+		}
+		if image.TarballPath == "" {
+			rootfs := filepath.Join(imagePath, "rootfs")
+			if rootfsInfo, err := os.Stat(rootfs); err != nil || !rootfsInfo.IsDir() {
+				return SavedImage{}, fmt.Errorf("expected '%s' to contain one of: image, image.tar, rootfs/", imagePath)
+			}
+			image.RootfsPath = rootfs
+			if image.Env, image.User, err = readRootfsMetadata(imagePath); err != nil {
+				return SavedImage{}, err
+			}
+		}
+	} else {
+		image.TarballPath = imagePath
+	}
+
+	if image.TarballPath != "" {
+		if image.Tag, image.Digest, err = inspectTarball(image.TarballPath); err != nil {
+			return SavedImage{}, fmt.Errorf("failed to read image tarball '%s': %s", image.TarballPath, err)
+		}
+	}
+
+	if repository := readMetadataFile(metadataDir, "repository"); repository != "" {
+		tag := readMetadataFile(metadataDir, "tag")
+		if tag == "" {
+			tag = "latest"
+		}
+		image.Tag = fmt.Sprintf("%s:%s", repository, tag)
+	}
+	if digest := readMetadataFile(metadataDir, "digest"); digest != "" {
+		image.Digest = digest
+	} else if image.Digest == "" {
+		image.Digest = readMetadataFile(metadataDir, "image-id")
+	}
+
+	return image, nil
+}
+
+// inspectTarball reads the tag and image ID from a `docker save`
+// tarball, or the tag and manifest digest from an OCI layout tarball.
+func inspectTarball(tarballPath string) (string, string, error) {
+	f, err := os.Open(tarballPath)
+	if err != nil {
+		return "", "", err
+	}
+	defer f.Close()
+
+	var manifests []dockerManifest
+	var index *ociIndex
+	reader := tar.NewReader(f)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", "", err
+		}
+
+		switch path.Clean(header.Name) {
+		case "manifest.json":
+			if err = json.NewDecoder(reader).Decode(&manifests); err != nil {
+				return "", "", fmt.Errorf("invalid manifest.json: %s", err)
+			}
+		case "index.json":
+			index = &ociIndex{}
+			if err = json.NewDecoder(reader).Decode(index); err != nil {
+				return "", "", fmt.Errorf("invalid index.json: %s", err)
+			}
+		}
+	}
+
+	if len(manifests) > 0 {
+		tag := ""
+		if len(manifests[0].RepoTags) > 0 {
+			tag = manifests[0].RepoTags[0]
+		}
+		// the config blob is named after its digest, which is the image ID
+		configName := strings.TrimSuffix(path.Base(manifests[0].Config), ".json")
+		return tag, "sha256:" + configName, nil
+	}
+	if index != nil && len(index.Manifests) > 0 {
+		manifest := index.Manifests[0]
+		tag := manifest.Annotations["io.containerd.image.name"]
+		if tag == "" {
+			tag = manifest.Annotations["org.opencontainers.image.ref.name"]
+		}
+		return tag, manifest.Digest, nil
+	}
+
+	return "", "", fmt.Errorf("found neither manifest.json nor index.json")
+}
+
+// readRootfsMetadata reads the env and user of the image from the
+// metadata.json written by the registry-image resource, if present.
+func readRootfsMetadata(dir string) ([]string, string, error) {
+	contents, err := ioutil.ReadFile(filepath.Join(dir, "metadata.json"))
+	if os.IsNotExist(err) {
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	metadata := rootfsMetadata{}
+	if err = json.Unmarshal(contents, &metadata); err != nil {
+		return nil, "", fmt.Errorf("invalid metadata.json in '%s': %s", dir, err)
+	}
+	return metadata.Env, metadata.User, nil
+}
+
+func readMetadataFile(dir string, name string) string {
+	contents, err := ioutil.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(contents))
+}
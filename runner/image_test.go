@@ -0,0 +1,250 @@
+package runner_test
+
+import (
+	"archive/tar"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ljfranklin/test-runner-resource/runner"
+	"github.com/ljfranklin/test-runner-resource/test/helpers"
+)
+
+const imageID = "4e9f8e5ce8d3a2bba3c4b1c0e5c1cf6ee0c7ff2fa4f1f36fb2e2c1bcbfbd0b6a"
+
+func writeTarball(t *testing.T, tarballPath string, files map[string]string) {
+	t.Helper()
+
+	f, err := os.Create(tarballPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	writer := tar.NewWriter(f)
+	for name, contents := range files {
+		err = writer.WriteHeader(&tar.Header{
+			Name: name,
+			Mode: 0644,
+			Size: int64(len(contents)),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = writer.Write([]byte(contents)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+
+	for name, contents := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLocateSavedImageFromDockerSaveTarball(t *testing.T) {
+	t.Parallel()
+
+	tmpDir, err := ioutil.TempDir("", "saved-image")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	tarballPath := filepath.Join(tmpDir, "image")
+	writeTarball(t, tarballPath, map[string]string{
+		imageID + ".json": "{}",
+		"manifest.json":   `[{"Config":"` + imageID + `.json","RepoTags":["golang:1.10"],"Layers":[]}]`,
+	})
+
+	savedImage, err := runner.LocateSavedImage(tarballPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	helpers.AssertEquals(t, savedImage, runner.SavedImage{
+		TarballPath: tarballPath,
+		Tag:         "golang:1.10",
+		Digest:      "sha256:" + imageID,
+	})
+
+	// metadata files from the docker-image resource take precedence
+	writeFiles(t, tmpDir, map[string]string{
+		"repository": "some-registry/some-image\n",
+		"tag":        "some-tag\n",
+	})
+	savedImage, err = runner.LocateSavedImage(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	helpers.AssertEquals(t, savedImage, runner.SavedImage{
+		TarballPath: tarballPath,
+		Tag:         "some-registry/some-image:some-tag",
+		Digest:      "sha256:" + imageID,
+	})
+}
+
+func TestLocateSavedImageFromOCITarball(t *testing.T) {
+	t.Parallel()
+
+	tmpDir, err := ioutil.TempDir("", "saved-image")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	tarballPath := filepath.Join(tmpDir, "image.tar")
+	writeTarball(t, tarballPath, map[string]string{
+		"oci-layout": `{"imageLayoutVersion":"1.0.0"}`,
+		"index.json": `{"manifests":[{"digest":"sha256:some-manifest-digest","annotations":{"io.containerd.image.name":"docker.io/library/golang:1.10"}}]}`,
+	})
+
+	savedImage, err := runner.LocateSavedImage(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	helpers.AssertEquals(t, savedImage, runner.SavedImage{
+		TarballPath: tarballPath,
+		Tag:         "docker.io/library/golang:1.10",
+		Digest:      "sha256:some-manifest-digest",
+	})
+}
+
+func TestLocateSavedImageFromRootfs(t *testing.T) {
+	t.Parallel()
+
+	tmpDir, err := ioutil.TempDir("", "saved-image")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err = os.MkdirAll(filepath.Join(tmpDir, "rootfs", "bin"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, tmpDir, map[string]string{
+		"metadata.json": `{"env":["PATH=/usr/local/go/bin:/usr/bin","GOPATH=/go"],"user":"nobody"}`,
+		"repository":    "golang",
+		"digest":        "sha256:some-registry-digest\n",
+	})
+
+	savedImage, err := runner.LocateSavedImage(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	helpers.AssertEquals(t, savedImage, runner.SavedImage{
+		RootfsPath: filepath.Join(tmpDir, "rootfs"),
+		Tag:        "golang:latest",
+		Digest:     "sha256:some-registry-digest",
+		Env:        []string{"PATH=/usr/local/go/bin:/usr/bin", "GOPATH=/go"},
+		User:       "nobody",
+	})
+}
+
+func TestLocateSavedImageMetadataFiles(t *testing.T) {
+	t.Parallel()
+
+	for _, testCase := range []struct {
+		name     string
+		files    map[string]string
+		expected runner.SavedImage
+	}{
+		{
+			name:     "no metadata",
+			files:    map[string]string{},
+			expected: runner.SavedImage{},
+		},
+		{
+			name:     "repository defaults to latest tag",
+			files:    map[string]string{"repository": "golang"},
+			expected: runner.SavedImage{Tag: "golang:latest"},
+		},
+		{
+			name:     "repository and tag",
+			files:    map[string]string{"repository": "golang", "tag": "1.10"},
+			expected: runner.SavedImage{Tag: "golang:1.10"},
+		},
+		{
+			name:     "image ID when there is no digest",
+			files:    map[string]string{"image-id": "sha256:some-image-id"},
+			expected: runner.SavedImage{Digest: "sha256:some-image-id"},
+		},
+		{
+			name:     "digest takes precedence over image ID",
+			files:    map[string]string{"digest": "sha256:some-digest", "image-id": "sha256:some-image-id"},
+			expected: runner.SavedImage{Digest: "sha256:some-digest"},
+		},
+	} {
+		tmpDir, err := ioutil.TempDir("", "saved-image")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(tmpDir)
+
+		if err = os.MkdirAll(filepath.Join(tmpDir, "rootfs"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		writeFiles(t, tmpDir, testCase.files)
+
+		savedImage, err := runner.LocateSavedImage(tmpDir)
+		if err != nil {
+			t.Fatalf("%s: %s", testCase.name, err)
+		}
+		testCase.expected.RootfsPath = filepath.Join(tmpDir, "rootfs")
+		helpers.AssertEquals(t, savedImage, testCase.expected)
+	}
+}
+
+func TestLocateSavedImageErrors(t *testing.T) {
+	t.Parallel()
+
+	tmpDir, err := ioutil.TempDir("", "saved-image")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	notATarball := filepath.Join(tmpDir, "not-a-tarball")
+	writeFiles(t, tmpDir, map[string]string{
+		"not-a-tarball": "some-contents",
+	})
+	invalidMetadata := filepath.Join(tmpDir, "invalid-metadata")
+	if err = os.MkdirAll(filepath.Join(invalidMetadata, "rootfs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, invalidMetadata, map[string]string{
+		"metadata.json": "{",
+	})
+	emptyTarball := filepath.Join(tmpDir, "empty.tar")
+	writeTarball(t, emptyTarball, map[string]string{
+		"some-layer.tar": "",
+	})
+
+	for _, testCase := range []struct {
+		path     string
+		expected string
+	}{
+		{path: filepath.Join(tmpDir, "missing"), expected: "failed to find image"},
+		{path: tmpDir, expected: "image, image.tar, rootfs/"},
+		{path: notATarball, expected: "failed to read image tarball"},
+		{path: emptyTarball, expected: "neither manifest.json nor index.json"},
+		{path: invalidMetadata, expected: "invalid metadata.json"},
+	} {
+		_, err := runner.LocateSavedImage(testCase.path)
+		if err == nil {
+			t.Fatalf("expected err for '%s' but none occurred", testCase.path)
+		}
+		if !strings.Contains(err.Error(), testCase.expected) {
+			t.Fatalf("expected err to contain '%s', but it did not: %s", testCase.expected, err)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package runner
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// Local runs the command directly in the resource container, for
+// images which already contain the test toolchain.
+type Local struct {
+	OutputWriter io.Writer
+}
+
+func (l Local) Run(config Config) error {
+	args := config.shellArgs()
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Dir = config.WorkDir
+	cmd.Env = append(os.Environ(), config.envVars()...)
+	cmd.Stdout = l.OutputWriter
+	cmd.Stderr = l.OutputWriter
+	// run in a new process group so a timeout also kills any children
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to run command with shell '%s': %s", args[0], err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	var timeout <-chan time.Time
+	if config.Timeout > 0 {
+		timer := time.NewTimer(config.Timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	var err error
+	select {
+	case err = <-done:
+	case <-timeout:
+		syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+		<-done
+		return CommandFailed{
+			ExitStatus: -1,
+			Timeout:    config.Timeout,
+		}
+	}
+
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			if status, ok := exitErr.Sys().(syscall.WaitStatus); ok {
+				return CommandFailed{
+					ExitStatus: status.ExitStatus(),
+				}
+			}
+		}
+		return fmt.Errorf("failed to run command with shell '%s': %s", args[0], err)
+	}
+	return nil
+}
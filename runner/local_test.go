@@ -0,0 +1,100 @@
+package runner_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ljfranklin/test-runner-resource/runner"
+	"github.com/ljfranklin/test-runner-resource/test/helpers"
+)
+
+func TestLocalRun(t *testing.T) {
+	t.Parallel()
+
+	tmpDir, err := ioutil.TempDir("", "local-runner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	output := bytes.Buffer{}
+	local := runner.Local{
+		OutputWriter: &output,
+	}
+
+	err = local.Run(runner.Config{
+		Command: `echo "$SOME_VAR in $(pwd)"; echo some-stderr >&2`,
+		WorkDir: tmpDir,
+		Shell:   "bash",
+		Env: map[string]string{
+			"SOME_VAR": "some-value",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{"some-value in " + tmpDir, "some-stderr"} {
+		if !strings.Contains(output.String(), expected) {
+			t.Fatalf("expected output to contain '%s' but it did not: %s", expected, output.String())
+		}
+	}
+}
+
+func TestLocalRunReturnsExitStatus(t *testing.T) {
+	t.Parallel()
+
+	local := runner.Local{
+		OutputWriter: ioutil.Discard,
+	}
+
+	err := local.Run(runner.Config{
+		Command: "exit 3",
+	})
+	helpers.AssertEquals(t, err, runner.CommandFailed{ExitStatus: 3})
+}
+
+func TestLocalRunKillsCommandOnTimeout(t *testing.T) {
+	t.Parallel()
+
+	local := runner.Local{
+		OutputWriter: ioutil.Discard,
+	}
+
+	start := time.Now()
+	err := local.Run(runner.Config{
+		// the child process also holds the output open until killed
+		Command: "sleep 30 & sleep 30",
+		Timeout: 100 * time.Millisecond,
+	})
+	helpers.AssertEquals(t, err, runner.CommandFailed{ExitStatus: -1, Timeout: 100 * time.Millisecond})
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Fatalf("expected command to be killed after timeout but it ran for %s", elapsed)
+	}
+}
+
+func TestLocalRunErrorOnMissingShell(t *testing.T) {
+	t.Parallel()
+
+	local := runner.Local{
+		OutputWriter: ioutil.Discard,
+	}
+
+	err := local.Run(runner.Config{
+		Command: "true",
+		Shell:   "some-missing-shell",
+	})
+	if err == nil {
+		t.Fatal("expected err to occur but it did not")
+	}
+	if _, ok := err.(runner.CommandFailed); ok {
+		t.Fatalf("expected a non-CommandFailed error but got: %s", err)
+	}
+	if !strings.Contains(err.Error(), "some-missing-shell") {
+		t.Fatalf("expected err to contain 'some-missing-shell', but it did not: %s", err)
+	}
+}
@@ -0,0 +1,61 @@
+package runner
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+type CommandFailed struct {
+	ExitStatus int
+	// set if the command was killed after running for this long
+	Timeout time.Duration
+}
+
+func (c CommandFailed) Error() string {
+	if c.Timeout > 0 {
+		return fmt.Sprintf("command timed out after %s", c.Timeout)
+	}
+	return fmt.Sprintf("command exited with status %d", c.ExitStatus)
+}
+
+type Config struct {
+	Image string
+	// loaded instead of pulling Image, see LocateSavedImage
+	SavedImage *SavedImage
+	Command    string
+	// the put's working directory, mounted into the container so
+	// every input is visible; defaults to WorkDir
+	SourceDir string
+	// where Command is run, within SourceDir
+	WorkDir string
+	// defaults to "sh", run as `<shell> -c <command>`
+	Shell string
+	Env   map[string]string
+	// zero for no timeout
+	Timeout time.Duration
+}
+
+func (c Config) shellArgs() []string {
+	shell := c.Shell
+	if shell == "" {
+		shell = "sh"
+	}
+	return []string{shell, "-c", c.Command}
+}
+
+// envVars returns Env as sorted NAME=value pairs.
+func (c Config) envVars() []string {
+	vars := []string{}
+	for name, value := range c.Env {
+		vars = append(vars, fmt.Sprintf("%s=%s", name, value))
+	}
+	sort.Strings(vars)
+	return vars
+}
+
+// go:generate counterfeiter . Runner
+
+type Runner interface {
+	Run(Config) error
+}
@@ -0,0 +1,310 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const azureAPIVersion = "2019-12-12"
+
+type azure struct {
+	accountName string
+	accountKey  []byte
+	sasToken    url.Values
+	container   string
+	prefix      string
+	endpoint    string
+	client      *http.Client
+}
+
+type azureBlobList struct {
+	Blobs []struct {
+		Name string `xml:"Name"`
+	} `xml:"Blobs>Blob"`
+	NextMarker string `xml:"NextMarker"`
+}
+
+func ValidateAzureConfig(config map[string]interface{}) error {
+	requiredProps := []string{
+		"account_name",
+		"container",
+	}
+
+	missingProps := []string{}
+	for _, required := range requiredProps {
+		if _, ok := config[required]; !ok {
+			missingProps = append(missingProps, required)
+		}
+	}
+	if len(missingProps) > 0 {
+		return fmt.Errorf("missing required properties in storage_config: %s", strings.Join(missingProps, ", "))
+	}
+
+	_, hasKey := config["account_key"]
+	_, hasSAS := config["sas_token"]
+	if hasKey == hasSAS {
+		return errors.New("storage_config must contain exactly one of: account_key, sas_token")
+	}
+	if hasKey {
+		if _, err := base64.StdEncoding.DecodeString(config["account_key"].(string)); err != nil {
+			return fmt.Errorf("account_key must be base64 encoded: %s", err)
+		}
+	}
+	if hasSAS {
+		if _, err := url.ParseQuery(strings.TrimPrefix(config["sas_token"].(string), "?")); err != nil {
+			return fmt.Errorf("failed to parse sas_token: %s", err)
+		}
+	}
+	return nil
+}
+
+func NewAzure(config map[string]interface{}) Storage {
+	a := &azure{
+		accountName: config["account_name"].(string),
+		container:   config["container"].(string),
+		client:      &http.Client{Timeout: 5 * time.Minute},
+	}
+	a.endpoint = fmt.Sprintf("https://%s.blob.core.windows.net", a.accountName)
+	if endpoint, ok := config["endpoint"]; ok {
+		// e.g. Azurite uses "http://127.0.0.1:10000/devstoreaccount1"
+		a.endpoint = strings.TrimSuffix(endpoint.(string), "/")
+	}
+	if prefix, ok := config["path_prefix"]; ok {
+		a.prefix = prefix.(string)
+	}
+	// already checked by ValidateAzureConfig
+	if accountKey, ok := config["account_key"]; ok {
+		a.accountKey, _ = base64.StdEncoding.DecodeString(accountKey.(string))
+	}
+	if sasToken, ok := config["sas_token"]; ok {
+		a.sasToken, _ = url.ParseQuery(strings.TrimPrefix(sasToken.(string), "?"))
+	}
+
+	return a
+}
+
+func (a *azure) Get(key string, destination io.Writer) error {
+	blobName := path.Join(a.prefix, key)
+	resp, err := a.do("GET", a.blobPath(blobName), nil, nil, nil)
+	if err != nil {
+		return fmt.Errorf("unable to fetch '%s': %s", blobName, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return FileNotFound{Key: key}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unable to fetch '%s': %s", blobName, responseError(resp))
+	}
+
+	_, err = io.Copy(destination, resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to copy download to local file: %s", err)
+	}
+
+	return nil
+}
+
+func (a *azure) Put(key string, source io.Reader) error {
+	blobName := path.Join(a.prefix, key)
+	contents, err := ioutil.ReadAll(source)
+	if err != nil {
+		return fmt.Errorf("unable to upload '%s': %s", blobName, err)
+	}
+
+	headers := map[string]string{
+		"x-ms-blob-type": "BlockBlob",
+		"Content-Type":   "application/octet-stream",
+	}
+	resp, err := a.do("PUT", a.blobPath(blobName), nil, contents, headers)
+	if err != nil {
+		return fmt.Errorf("unable to upload '%s': %s", blobName, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("unable to upload '%s': %s", blobName, responseError(resp))
+	}
+
+	return nil
+}
+
+func (a *azure) Delete(key string) error {
+	blobName := path.Join(a.prefix, key)
+	resp, err := a.do("DELETE", a.blobPath(blobName), nil, nil, nil)
+	if err != nil {
+		return fmt.Errorf("unable to delete '%s': %s", blobName, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return FileNotFound{Key: key}
+	}
+	if resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("unable to delete '%s': %s", blobName, responseError(resp))
+	}
+
+	return nil
+}
+
+func (a *azure) List() ([]string, error) {
+	prefix := a.prefix
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	results := []string{}
+	marker := ""
+	for {
+		query := url.Values{}
+		query.Set("restype", "container")
+		query.Set("comp", "list")
+		if prefix != "" {
+			query.Set("prefix", prefix)
+		}
+		if marker != "" {
+			query.Set("marker", marker)
+		}
+
+		page, err := a.listPage(query)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list container '%s' with '%s': %s", a.container, a.prefix, err)
+		}
+		for _, blob := range page.Blobs {
+			results = append(results, path.Base(blob.Name))
+		}
+		if page.NextMarker == "" {
+			return results, nil
+		}
+		marker = page.NextMarker
+	}
+}
+
+func (a *azure) listPage(query url.Values) (azureBlobList, error) {
+	resp, err := a.do("GET", "/"+url.PathEscape(a.container), query, nil, nil)
+	if err != nil {
+		return azureBlobList{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return azureBlobList{}, responseError(resp)
+	}
+	var page azureBlobList
+	if err = xml.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return azureBlobList{}, fmt.Errorf("failed to parse list response: %s", err)
+	}
+	return page, nil
+}
+
+func (a *azure) blobPath(blobName string) string {
+	segments := []string{url.PathEscape(a.container)}
+	for _, segment := range strings.Split(blobName, "/") {
+		segments = append(segments, url.PathEscape(segment))
+	}
+	return "/" + strings.Join(segments, "/")
+}
+
+func (a *azure) do(method string, resourcePath string, query url.Values, body []byte, headers map[string]string) (*http.Response, error) {
+	if query == nil {
+		query = url.Values{}
+	}
+	for name, values := range a.sasToken {
+		query[name] = values
+	}
+
+	requestURL, err := url.Parse(a.endpoint)
+	if err != nil {
+		return nil, err
+	}
+	requestURL.RawPath = requestURL.EscapedPath() + resourcePath
+	requestURL.Path, err = url.PathUnescape(requestURL.RawPath)
+	if err != nil {
+		return nil, err
+	}
+	requestURL.RawQuery = query.Encode()
+
+	req, err := http.NewRequest(method, requestURL.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	req.Header.Set("x-ms-date", time.Now().UTC().Format(http.TimeFormat))
+	req.Header.Set("x-ms-version", azureAPIVersion)
+	if a.accountKey != nil {
+		req.Header.Set("Authorization", fmt.Sprintf("SharedKey %s:%s", a.accountName, a.sign(req, len(body))))
+	}
+
+	return a.client.Do(req)
+}
+
+// sign implements Shared Key authorization, see
+// https://docs.microsoft.com/en-us/rest/api/storageservices/authorize-with-shared-key
+func (a *azure) sign(req *http.Request, contentLength int) string {
+	lengthHeader := ""
+	if contentLength > 0 {
+		lengthHeader = strconv.Itoa(contentLength)
+	}
+
+	msHeaders := []string{}
+	for name := range req.Header {
+		if lower := strings.ToLower(name); strings.HasPrefix(lower, "x-ms-") {
+			msHeaders = append(msHeaders, lower)
+		}
+	}
+	sort.Strings(msHeaders)
+	canonicalizedHeaders := ""
+	for _, name := range msHeaders {
+		canonicalizedHeaders += fmt.Sprintf("%s:%s\n", name, strings.TrimSpace(req.Header.Get(name)))
+	}
+
+	canonicalizedResource := fmt.Sprintf("/%s%s", a.accountName, req.URL.EscapedPath())
+	query := req.URL.Query()
+	queryNames := []string{}
+	for name := range query {
+		queryNames = append(queryNames, name)
+	}
+	sort.Strings(queryNames)
+	for _, name := range queryNames {
+		values := query[name]
+		sort.Strings(values)
+		canonicalizedResource += fmt.Sprintf("\n%s:%s", strings.ToLower(name), strings.Join(values, ","))
+	}
+
+	stringToSign := strings.Join([]string{
+		req.Method,
+		req.Header.Get("Content-Encoding"),
+		req.Header.Get("Content-Language"),
+		lengthHeader,
+		req.Header.Get("Content-MD5"),
+		req.Header.Get("Content-Type"),
+		"", // Date, superseded by x-ms-date
+		req.Header.Get("If-Modified-Since"),
+		req.Header.Get("If-Match"),
+		req.Header.Get("If-None-Match"),
+		req.Header.Get("If-Unmodified-Since"),
+		req.Header.Get("Range"),
+		canonicalizedHeaders + canonicalizedResource,
+	}, "\n")
+
+	mac := hmac.New(sha256.New, a.accountKey)
+	mac.Write([]byte(stringToSign))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
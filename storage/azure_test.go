@@ -0,0 +1,326 @@
+package storage_test
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ljfranklin/test-runner-resource/storage"
+	"github.com/ljfranklin/test-runner-resource/test/helpers"
+)
+
+const (
+	azureAccountName = "devstoreaccount1"
+	// the well-known Azurite development key
+	azureAccountKey = "Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IFsuFq2UVErCz4I6tq/K1SZFPTOtr/KBHBeksoGMGw=="
+	azureSASToken   = "sv=2019-12-12&ss=b&srt=sco&sp=rwdlac&sig=some-signature"
+)
+
+// fakeAzure implements the subset of the Blob service REST API used by
+// the azure storage backend, with paths in the Azurite style of
+// /<account>/<container>/<blob>.
+type fakeAzure struct {
+	t        *testing.T
+	useSAS   bool
+	pageSize int
+
+	mutex sync.Mutex
+	blobs map[string][]byte
+}
+
+func newFakeAzure(t *testing.T, useSAS bool) (*fakeAzure, *httptest.Server) {
+	fake := &fakeAzure{
+		t:        t,
+		useSAS:   useSAS,
+		pageSize: 2,
+		blobs:    map[string][]byte{},
+	}
+	return fake, httptest.NewServer(fake)
+}
+
+func (f *fakeAzure) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if !f.authorized(r) {
+		http.Error(w, "AuthenticationFailed", http.StatusForbidden)
+		return
+	}
+
+	name := strings.TrimPrefix(r.URL.Path, "/"+azureAccountName+"/some-container")
+	if name == "" && r.URL.Query().Get("comp") == "list" {
+		f.serveList(w, r)
+		return
+	}
+	name = strings.TrimPrefix(name, "/")
+
+	switch r.Method {
+	case "PUT":
+		if r.Header.Get("x-ms-blob-type") != "BlockBlob" {
+			http.Error(w, "missing blob type", http.StatusBadRequest)
+			return
+		}
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			f.t.Fatal(err)
+		}
+		f.blobs[name] = body
+		w.WriteHeader(http.StatusCreated)
+	case "GET":
+		contents, ok := f.blobs[name]
+		if !ok {
+			http.Error(w, "BlobNotFound", http.StatusNotFound)
+			return
+		}
+		w.Write(contents)
+	case "DELETE":
+		if _, ok := f.blobs[name]; !ok {
+			http.Error(w, "BlobNotFound", http.StatusNotFound)
+			return
+		}
+		delete(f.blobs, name)
+		w.WriteHeader(http.StatusAccepted)
+	default:
+		http.Error(w, "unexpected request", http.StatusBadRequest)
+	}
+}
+
+func (f *fakeAzure) authorized(r *http.Request) bool {
+	if f.useSAS {
+		return r.URL.Query().Get("sig") == "some-signature" && r.Header.Get("Authorization") == ""
+	}
+
+	contentLength := ""
+	if r.ContentLength > 0 {
+		contentLength = strconv.FormatInt(r.ContentLength, 10)
+	}
+	headers := []string{}
+	for name := range r.Header {
+		if strings.HasPrefix(strings.ToLower(name), "x-ms-") {
+			headers = append(headers, fmt.Sprintf("%s:%s\n", strings.ToLower(name), r.Header.Get(name)))
+		}
+	}
+	sort.Strings(headers)
+	resource := "/" + azureAccountName + r.URL.EscapedPath()
+	query := r.URL.Query()
+	params := []string{}
+	for name := range query {
+		params = append(params, fmt.Sprintf("\n%s:%s", name, strings.Join(query[name], ",")))
+	}
+	sort.Strings(params)
+
+	stringToSign := r.Method + "\n\n\n" + contentLength + "\n\n" + r.Header.Get("Content-Type") + "\n\n\n\n\n\n\n" +
+		strings.Join(headers, "") + resource + strings.Join(params, "")
+
+	key, err := base64.StdEncoding.DecodeString(azureAccountKey)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(stringToSign))
+	expected := fmt.Sprintf("SharedKey %s:%s", azureAccountName, base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+	return r.Header.Get("Authorization") == expected
+}
+
+func (f *fakeAzure) serveList(w http.ResponseWriter, r *http.Request) {
+	names := []string{}
+	for name := range f.blobs {
+		if strings.HasPrefix(name, r.URL.Query().Get("prefix")) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	offset := 0
+	if marker := r.URL.Query().Get("marker"); marker != "" {
+		offset, _ = strconv.Atoi(marker)
+	}
+	type blob struct {
+		Name string `xml:"Name"`
+	}
+	response := struct {
+		XMLName    xml.Name `xml:"EnumerationResults"`
+		Blobs      []blob   `xml:"Blobs>Blob"`
+		NextMarker string   `xml:"NextMarker"`
+	}{}
+	for i := offset; i < len(names) && i < offset+f.pageSize; i++ {
+		response.Blobs = append(response.Blobs, blob{Name: names[i]})
+	}
+	if offset+f.pageSize < len(names) {
+		response.NextMarker = strconv.Itoa(offset + f.pageSize)
+	}
+	xml.NewEncoder(w).Encode(response)
+}
+
+func buildAzureConfig(t *testing.T, useSAS bool) (map[string]interface{}, *fakeAzure, func()) {
+	t.Helper()
+
+	fake, server := newFakeAzure(t, useSAS)
+	config := map[string]interface{}{
+		"account_name": azureAccountName,
+		"container":    "some-container",
+		"endpoint":     server.URL + "/" + azureAccountName,
+		"path_prefix":  "results",
+	}
+	if useSAS {
+		config["sas_token"] = "?" + azureSASToken
+	} else {
+		config["account_key"] = azureAccountKey
+	}
+	return config, fake, server.Close
+}
+
+func TestAzurePutAndGet(t *testing.T) {
+	t.Parallel()
+
+	for _, useSAS := range []bool{false, true} {
+		config, fake, cleanup := buildAzureConfig(t, useSAS)
+		defer cleanup()
+
+		azure, err := storage.New("azure", config)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		fixture, err := ioutil.ReadFile(fixturePath("some-file"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = azure.Put("some-key", bytes.NewReader(fixture)); err != nil {
+			t.Fatal(err)
+		}
+		helpers.AssertEquals(t, string(fake.blobs["results/some-key"]), "some-file-contents\n")
+
+		fileContents := bytes.Buffer{}
+		if err = azure.Get("some-key", &fileContents); err != nil {
+			t.Fatal(err)
+		}
+		helpers.AssertEquals(t, fileContents.String(), "some-file-contents\n")
+
+		err = azure.Get("key-that-does-not-exist", &fileContents)
+		if _, ok := err.(storage.FileNotFound); !ok {
+			t.Fatalf("expected FileNotFound error but got: %v", err)
+		}
+	}
+}
+
+func TestAzurePutErrorOnInvalidInputFile(t *testing.T) {
+	t.Parallel()
+
+	config, _, cleanup := buildAzureConfig(t, false)
+	defer cleanup()
+
+	azure, err := storage.New("azure", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = azure.Put("some-upload-path", errReader{})
+	if err == nil {
+		t.Fatal("expected an error on invalid reader")
+	}
+	if !strings.Contains(err.Error(), "some-read-error") {
+		t.Fatalf("expected '%s' to contain 'some-read-error'", err.Error())
+	}
+}
+
+func TestAzureErrorOnInvalidCreds(t *testing.T) {
+	t.Parallel()
+
+	config, _, cleanup := buildAzureConfig(t, false)
+	defer cleanup()
+	config["account_key"] = base64.StdEncoding.EncodeToString([]byte("some-wrong-key"))
+
+	azure, err := storage.New("azure", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = azure.Put("some-key", strings.NewReader("some-contents"))
+	if err == nil {
+		t.Fatal("expected an error on invalid credentials")
+	}
+	if !strings.Contains(err.Error(), "AuthenticationFailed") {
+		t.Fatalf("expected '%s' to contain 'AuthenticationFailed'", err.Error())
+	}
+}
+
+func TestAzureDelete(t *testing.T) {
+	t.Parallel()
+
+	config, fake, cleanup := buildAzureConfig(t, false)
+	defer cleanup()
+	fake.blobs["results/some-key"] = []byte("some-contents")
+
+	azure, err := storage.New("azure", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = azure.Delete("some-key"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := fake.blobs["results/some-key"]; ok {
+		t.Fatal("expected blob to be deleted but it was not")
+	}
+
+	err = azure.Delete("some-key")
+	if _, ok := err.(storage.FileNotFound); !ok {
+		t.Fatalf("expected FileNotFound error but got: %v", err)
+	}
+}
+
+func TestAzureListPaginates(t *testing.T) {
+	t.Parallel()
+
+	config, fake, cleanup := buildAzureConfig(t, false)
+	defer cleanup()
+	for _, name := range []string{"results/key-a", "results/key-b", "results/key-c", "other/key-d"} {
+		fake.blobs[name] = []byte(name)
+	}
+
+	azure, err := storage.New("azure", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := azure.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	helpers.AssertEquals(t, results, []string{"key-a", "key-b", "key-c"})
+}
+
+func TestAzureErrorOnInvalidConfig(t *testing.T) {
+	t.Parallel()
+
+	_, err := storage.New("azure", map[string]interface{}{})
+	if err == nil {
+		t.Fatal("expected error on missing config but none occurred")
+	}
+	if !strings.Contains(err.Error(), "account_name, container") {
+		t.Fatalf("expected error to contain 'account_name, container' but it did not: %s", err)
+	}
+
+	_, err = storage.New("azure", map[string]interface{}{
+		"account_name": azureAccountName,
+		"container":    "some-container",
+	})
+	if err == nil {
+		t.Fatal("expected error on missing credentials but none occurred")
+	}
+	if !strings.Contains(err.Error(), "account_key, sas_token") {
+		t.Fatalf("expected error to contain 'account_key, sas_token' but it did not: %s", err)
+	}
+}
@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sync"
+)
+
+// disabled keeps results in memory for the lifetime of the process so
+// tests can run without configuring a bucket. No history is persisted.
+type disabled struct {
+	mutex sync.Mutex
+	files map[string][]byte
+}
+
+func NewDisabled() Storage {
+	return &disabled{
+		files: map[string][]byte{},
+	}
+}
+
+// IsDisabled reports whether s persists nothing between steps.
+func IsDisabled(s Storage) bool {
+	_, ok := s.(*disabled)
+	return ok
+}
+
+func (d *disabled) Get(key string, destination io.Writer) error {
+	d.mutex.Lock()
+	contents, ok := d.files[key]
+	d.mutex.Unlock()
+	if !ok {
+		return FileNotFound{Key: key}
+	}
+
+	_, err := io.Copy(destination, bytes.NewReader(contents))
+	if err != nil {
+		return fmt.Errorf("failed to copy download to local file: %s", err)
+	}
+
+	return nil
+}
+
+func (d *disabled) Put(key string, source io.Reader) error {
+	contents, err := ioutil.ReadAll(source)
+	if err != nil {
+		return fmt.Errorf("unable to upload '%s': %s", key, err)
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.files[key] = contents
+
+	return nil
+}
+
+func (d *disabled) Delete(key string) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if _, ok := d.files[key]; !ok {
+		return FileNotFound{Key: key}
+	}
+	delete(d.files, key)
+
+	return nil
+}
+
+func (d *disabled) List() ([]string, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	results := []string{}
+	for key := range d.files {
+		results = append(results, key)
+	}
+
+	return results, nil
+}
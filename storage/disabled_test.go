@@ -0,0 +1,61 @@
+package storage_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ljfranklin/test-runner-resource/storage"
+	"github.com/ljfranklin/test-runner-resource/test/helpers"
+)
+
+func TestDisabled(t *testing.T) {
+	t.Parallel()
+
+	disabled, err := storage.New("disabled", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	helpers.AssertEquals(t, storage.IsDisabled(disabled), true)
+
+	results, err := disabled.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	helpers.AssertEquals(t, results, []string{})
+
+	if err = disabled.Put("some-key", strings.NewReader("some-contents")); err != nil {
+		t.Fatal(err)
+	}
+	fileContents := bytes.Buffer{}
+	if err = disabled.Get("some-key", &fileContents); err != nil {
+		t.Fatal(err)
+	}
+	helpers.AssertEquals(t, fileContents.String(), "some-contents")
+
+	results, err = disabled.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	helpers.AssertEquals(t, results, []string{"some-key"})
+
+	if err = disabled.Delete("some-key"); err != nil {
+		t.Fatal(err)
+	}
+	err = disabled.Get("some-key", &fileContents)
+	if _, ok := err.(storage.FileNotFound); !ok {
+		t.Fatalf("expected FileNotFound error but got: %v", err)
+	}
+}
+
+func TestDisabledFromStorageConfig(t *testing.T) {
+	t.Parallel()
+
+	disabled, err := storage.New("s3", map[string]interface{}{
+		"disabled": true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	helpers.AssertEquals(t, storage.IsDisabled(disabled), true)
+}
@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type filesystem struct {
+	dir string
+}
+
+func ValidateFilesystemConfig(config map[string]interface{}) error {
+	if _, ok := config["path"]; !ok {
+		return fmt.Errorf("missing required properties in storage_config: path")
+	}
+	return nil
+}
+
+func NewFilesystem(config map[string]interface{}) Storage {
+	dir := config["path"].(string)
+	if prefix, ok := config["path_prefix"]; ok {
+		dir = filepath.Join(dir, prefix.(string))
+	}
+
+	return &filesystem{
+		dir: dir,
+	}
+}
+
+func (f *filesystem) Get(key string, destination io.Writer) error {
+	file, err := os.Open(filepath.Join(f.dir, key))
+	if os.IsNotExist(err) {
+		return FileNotFound{Key: key}
+	}
+	if err != nil {
+		return fmt.Errorf("unable to fetch '%s': %s", key, err)
+	}
+	defer file.Close()
+
+	_, err = io.Copy(destination, file)
+	if err != nil {
+		return fmt.Errorf("failed to copy download to local file: %s", err)
+	}
+
+	return nil
+}
+
+// Put writes to a hidden temp file and renames it into place so
+// readers never observe a partially written file.
+func (f *filesystem) Put(key string, source io.Reader) error {
+	path := filepath.Join(f.dir, key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("unable to upload '%s': %s", key, err)
+	}
+
+	tmpFile, err := ioutil.TempFile(filepath.Dir(path), fmt.Sprintf(".%s.tmp-", filepath.Base(key)))
+	if err != nil {
+		return fmt.Errorf("unable to upload '%s': %s", key, err)
+	}
+	defer os.Remove(tmpFile.Name())
+	defer tmpFile.Close()
+
+	if _, err = io.Copy(tmpFile, source); err != nil {
+		return fmt.Errorf("unable to upload '%s': %s", key, err)
+	}
+	if err = tmpFile.Sync(); err != nil {
+		return fmt.Errorf("unable to upload '%s': %s", key, err)
+	}
+	if err = tmpFile.Close(); err != nil {
+		return fmt.Errorf("unable to upload '%s': %s", key, err)
+	}
+	if err = os.Chmod(tmpFile.Name(), 0644); err != nil {
+		return fmt.Errorf("unable to upload '%s': %s", key, err)
+	}
+	if err = os.Rename(tmpFile.Name(), path); err != nil {
+		return fmt.Errorf("unable to upload '%s': %s", key, err)
+	}
+
+	return nil
+}
+
+func (f *filesystem) Delete(key string) error {
+	err := os.Remove(filepath.Join(f.dir, key))
+	if os.IsNotExist(err) {
+		return FileNotFound{Key: key}
+	}
+	if err != nil {
+		return fmt.Errorf("unable to delete '%s': %s", key, err)
+	}
+
+	return nil
+}
+
+func (f *filesystem) List() ([]string, error) {
+	files, err := ioutil.ReadDir(f.dir)
+	if os.IsNotExist(err) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to list '%s': %s", f.dir, err)
+	}
+
+	results := []string{}
+	for _, file := range files {
+		// skip directories and in-progress uploads
+		if file.IsDir() || strings.HasPrefix(file.Name(), ".") {
+			continue
+		}
+		results = append(results, file.Name())
+	}
+
+	return results, nil
+}
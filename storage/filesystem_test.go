@@ -0,0 +1,186 @@
+package storage_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/ljfranklin/test-runner-resource/storage"
+	"github.com/ljfranklin/test-runner-resource/test/helpers"
+)
+
+func TestFilesystemGet(t *testing.T) {
+	t.Parallel()
+
+	rootDir, err := ioutil.TempDir("", "filesystem-storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(rootDir)
+
+	if err = os.MkdirAll(filepath.Join(rootDir, "results"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(rootDir, "results", "some-key"), []byte("some-file-contents\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	filesystem, err := storage.New("filesystem", map[string]interface{}{
+		"path":        rootDir,
+		"path_prefix": "results",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fileContents := bytes.Buffer{}
+	if err = filesystem.Get("some-key", &fileContents); err != nil {
+		t.Fatal(err)
+	}
+	helpers.AssertEquals(t, fileContents.String(), "some-file-contents\n")
+
+	err = filesystem.Get("key-that-does-not-exist", &fileContents)
+	if _, ok := err.(storage.FileNotFound); !ok {
+		t.Fatalf("expected FileNotFound error but got: %v", err)
+	}
+}
+
+func TestFilesystemPut(t *testing.T) {
+	t.Parallel()
+
+	rootDir, err := ioutil.TempDir("", "filesystem-storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(rootDir)
+
+	filesystem, err := storage.New("filesystem", map[string]interface{}{
+		"path":        rootDir,
+		"path_prefix": "nested/results",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fixture, err := os.Open(fixturePath("some-file"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fixture.Close()
+
+	if err = filesystem.Put("some-key", fixture); err != nil {
+		t.Fatal(err)
+	}
+
+	contents, err := ioutil.ReadFile(filepath.Join(rootDir, "nested", "results", "some-key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	helpers.AssertEquals(t, string(contents), "some-file-contents\n")
+
+	err = filesystem.Put("some-other-key", errReader{})
+	if err == nil {
+		t.Fatal("expected an error on invalid reader")
+	}
+	if !strings.Contains(err.Error(), "some-read-error") {
+		t.Fatalf("expected '%s' to contain 'some-read-error'", err.Error())
+	}
+
+	// failed uploads must not leave partial or temp files behind
+	files, err := ioutil.ReadDir(filepath.Join(rootDir, "nested", "results"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	helpers.AssertEquals(t, len(files), 1)
+}
+
+func TestFilesystemDelete(t *testing.T) {
+	t.Parallel()
+
+	rootDir, err := ioutil.TempDir("", "filesystem-storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(rootDir)
+
+	filesystem, err := storage.New("filesystem", map[string]interface{}{
+		"path": rootDir,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = filesystem.Put("some-key", strings.NewReader("some-contents")); err != nil {
+		t.Fatal(err)
+	}
+	if err = filesystem.Delete("some-key"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(filepath.Join(rootDir, "some-key")); !os.IsNotExist(err) {
+		t.Fatalf("expected 'some-key' to be deleted but it was not")
+	}
+
+	err = filesystem.Delete("some-key")
+	if _, ok := err.(storage.FileNotFound); !ok {
+		t.Fatalf("expected FileNotFound error but got: %v", err)
+	}
+}
+
+func TestFilesystemList(t *testing.T) {
+	t.Parallel()
+
+	rootDir, err := ioutil.TempDir("", "filesystem-storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(rootDir)
+
+	filesystem, err := storage.New("filesystem", map[string]interface{}{
+		"path":        rootDir,
+		"path_prefix": "results",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := filesystem.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	helpers.AssertEquals(t, results, []string{})
+
+	for _, key := range []string{"key-b", "key-a"} {
+		if err = filesystem.Put(key, strings.NewReader(key)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = os.MkdirAll(filepath.Join(rootDir, "results", "some-dir"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(rootDir, "results", ".key-c.tmp-123"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err = filesystem.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(results)
+	helpers.AssertEquals(t, results, []string{"key-a", "key-b"})
+}
+
+func TestFilesystemErrorOnMissingConfig(t *testing.T) {
+	t.Parallel()
+
+	_, err := storage.New("filesystem", map[string]interface{}{})
+	if err == nil {
+		t.Fatal("expected error on missing config but none occurred")
+	}
+	if !strings.Contains(err.Error(), "path") {
+		t.Fatalf("expected error to contain 'path' but it did not: %s", err)
+	}
+}
@@ -0,0 +1,492 @@
+package storage
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultGCSEndpoint = "https://storage.googleapis.com"
+	gcsScope           = "https://www.googleapis.com/auth/devstorage.read_write"
+	// resumable uploads require chunks to be a multiple of 256 KiB
+	gcsChunkMultiple    = 256 * 1024
+	defaultGCSChunkSize = 32 * gcsChunkMultiple
+)
+
+type gcs struct {
+	bucket    string
+	prefix    string
+	endpoint  string
+	chunkSize int
+	key       *gcsKey
+	client    *http.Client
+
+	tokenMutex  sync.Mutex
+	token       string
+	tokenExpiry time.Time
+}
+
+type gcsKey struct {
+	ClientEmail  string `json:"client_email"`
+	PrivateKeyID string `json:"private_key_id"`
+	PrivateKey   string `json:"private_key"`
+	TokenURI     string `json:"token_uri"`
+
+	signer *rsa.PrivateKey
+}
+
+type gcsObjectList struct {
+	Items []struct {
+		Name string `json:"name"`
+	} `json:"items"`
+	NextPageToken string `json:"nextPageToken"`
+}
+
+// ValidateGCSConfig requires a json_key unless a custom endpoint is
+// set, e.g. a local fake GCS server which does not check credentials.
+func ValidateGCSConfig(config map[string]interface{}) error {
+	requiredProps := []string{
+		"bucket",
+	}
+	if _, ok := config["endpoint"]; !ok {
+		requiredProps = append(requiredProps, "json_key")
+	}
+
+	missingProps := []string{}
+	for _, required := range requiredProps {
+		if _, ok := config[required]; !ok {
+			missingProps = append(missingProps, required)
+		}
+	}
+	if len(missingProps) > 0 {
+		return fmt.Errorf("missing required properties in storage_config: %s", strings.Join(missingProps, ", "))
+	}
+
+	if jsonKey, ok := config["json_key"]; ok {
+		if _, err := parseGCSKey(jsonKey.(string)); err != nil {
+			return err
+		}
+	}
+	if chunkSize, ok := config["chunk_size"]; ok {
+		if size, ok := chunkSize.(float64); !ok || size <= 0 || int(size)%gcsChunkMultiple != 0 {
+			return fmt.Errorf("chunk_size must be a positive multiple of %d", gcsChunkMultiple)
+		}
+	}
+	return nil
+}
+
+func NewGCS(config map[string]interface{}) Storage {
+	g := &gcs{
+		bucket:    config["bucket"].(string),
+		endpoint:  defaultGCSEndpoint,
+		chunkSize: defaultGCSChunkSize,
+		client:    &http.Client{Timeout: 5 * time.Minute},
+	}
+	if prefix, ok := config["path_prefix"]; ok {
+		g.prefix = prefix.(string)
+	}
+	if endpoint, ok := config["endpoint"]; ok {
+		g.endpoint = strings.TrimSuffix(endpoint.(string), "/")
+	}
+	if chunkSize, ok := config["chunk_size"]; ok {
+		g.chunkSize = int(chunkSize.(float64))
+	}
+	if jsonKey, ok := config["json_key"]; ok {
+		// already checked by ValidateGCSConfig
+		g.key, _ = parseGCSKey(jsonKey.(string))
+	}
+
+	return g
+}
+
+func parseGCSKey(jsonKey string) (*gcsKey, error) {
+	var key gcsKey
+	if err := json.Unmarshal([]byte(jsonKey), &key); err != nil {
+		return nil, fmt.Errorf("failed to parse json_key: %s", err)
+	}
+	if key.ClientEmail == "" || key.PrivateKey == "" {
+		return nil, errors.New("json_key must contain 'client_email' and 'private_key'")
+	}
+	if key.TokenURI == "" {
+		key.TokenURI = "https://oauth2.googleapis.com/token"
+	}
+
+	block, _ := pem.Decode([]byte(key.PrivateKey))
+	if block == nil {
+		return nil, errors.New("failed to decode json_key private_key as PEM")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse json_key private_key: %s", err)
+	}
+	signer, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("json_key private_key must be an RSA key")
+	}
+	key.signer = signer
+
+	return &key, nil
+}
+
+func (g *gcs) Get(key string, destination io.Writer) error {
+	objectName := path.Join(g.prefix, key)
+	resp, err := g.do("GET", g.objectURL(objectName)+"?alt=media", nil, nil)
+	if err != nil {
+		return fmt.Errorf("unable to fetch '%s': %s", objectName, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return FileNotFound{Key: key}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unable to fetch '%s': %s", objectName, responseError(resp))
+	}
+
+	_, err = io.Copy(destination, resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to copy download to local file: %s", err)
+	}
+
+	return nil
+}
+
+// Put uses a resumable upload so that large files are sent in chunks
+// and a chunk interrupted by a transient error is resumed rather than
+// restarting the whole upload.
+func (g *gcs) Put(key string, source io.Reader) error {
+	objectName := path.Join(g.prefix, key)
+
+	sessionURL, err := g.startResumableUpload(objectName)
+	if err != nil {
+		return fmt.Errorf("unable to upload '%s': %s", objectName, err)
+	}
+
+	chunk := make([]byte, g.chunkSize)
+	offset := 0
+	for {
+		n, readErr := io.ReadFull(source, chunk)
+		if readErr != nil && readErr != io.EOF && readErr != io.ErrUnexpectedEOF {
+			return fmt.Errorf("unable to upload '%s': %s", objectName, readErr)
+		}
+		last := readErr != nil
+		total := -1
+		if last {
+			total = offset + n
+		}
+
+		if err = g.uploadChunk(sessionURL, chunk[:n], offset, total); err != nil {
+			return fmt.Errorf("unable to upload '%s': %s", objectName, err)
+		}
+		offset += n
+		if last {
+			return nil
+		}
+	}
+}
+
+func (g *gcs) startResumableUpload(objectName string) (string, error) {
+	uploadURL := fmt.Sprintf("%s/upload/storage/v1/b/%s/o?uploadType=resumable&name=%s",
+		g.endpoint, url.PathEscape(g.bucket), url.QueryEscape(objectName))
+	headers := map[string]string{
+		"Content-Type": "application/json; charset=UTF-8",
+	}
+	resp, err := g.do("POST", uploadURL, []byte("{}"), headers)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return "", responseError(resp)
+	}
+	sessionURL := resp.Header.Get("Location")
+	if sessionURL == "" {
+		return "", errors.New("resumable upload response did not include a session URL")
+	}
+	return sessionURL, nil
+}
+
+// uploadChunk sends data starting at offset. total is the size of the
+// whole object when data is the final chunk and -1 otherwise.
+func (g *gcs) uploadChunk(sessionURL string, data []byte, offset int, total int) error {
+	totalHeader := "*"
+	if total >= 0 {
+		totalHeader = strconv.Itoa(total)
+	}
+
+	var lastErr error
+	sent := 0
+	for attempt := 0; attempt < maxRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(time.Duration(attempt) * 100 * time.Millisecond)
+
+			// ask the server how much of the chunk it already has
+			persisted, err := g.persistedBytes(sessionURL, totalHeader)
+			if err != nil {
+				lastErr = err
+				continue
+			}
+			if persisted < 0 {
+				// upload is already complete
+				return nil
+			}
+			sent = persisted - offset
+			if sent < 0 || sent > len(data) {
+				return fmt.Errorf("server reported unexpected upload offset %d", persisted)
+			}
+		}
+
+		remaining := data[sent:]
+		contentRange := fmt.Sprintf("bytes */%s", totalHeader)
+		if len(remaining) > 0 {
+			start := offset + sent
+			contentRange = fmt.Sprintf("bytes %d-%d/%s", start, start+len(remaining)-1, totalHeader)
+		}
+		resp, err := g.do("PUT", sessionURL, remaining, map[string]string{"Content-Range": contentRange})
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		resp.Body.Close()
+
+		switch {
+		case resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusCreated:
+			return nil
+		case resp.StatusCode == http.StatusPermanentRedirect:
+			persisted, err := parseRangeHeader(resp.Header.Get("Range"))
+			if err != nil {
+				return err
+			}
+			if total < 0 && persisted == offset+len(data) {
+				return nil
+			}
+			lastErr = fmt.Errorf("server persisted %d of %d bytes", persisted, offset+len(data))
+		case resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests:
+			lastErr = responseError(resp)
+		default:
+			return responseError(resp)
+		}
+	}
+
+	return fmt.Errorf("failed after %d attempts: %s", maxRetries, lastErr)
+}
+
+// persistedBytes returns the number of bytes the server has stored for
+// the upload session, or -1 if the upload has already completed.
+func (g *gcs) persistedBytes(sessionURL string, totalHeader string) (int, error) {
+	resp, err := g.do("PUT", sessionURL, nil, map[string]string{"Content-Range": fmt.Sprintf("bytes */%s", totalHeader)})
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+		return -1, nil
+	case http.StatusPermanentRedirect:
+		return parseRangeHeader(resp.Header.Get("Range"))
+	default:
+		return 0, responseError(resp)
+	}
+}
+
+// parseRangeHeader converts a Range header such as "bytes=0-42" into
+// the number of persisted bytes. The header is absent when nothing
+// has been persisted.
+func parseRangeHeader(rangeHeader string) (int, error) {
+	if rangeHeader == "" {
+		return 0, nil
+	}
+	end, err := strconv.Atoi(rangeHeader[strings.LastIndex(rangeHeader, "-")+1:])
+	if err != nil {
+		return 0, fmt.Errorf("invalid Range header '%s'", rangeHeader)
+	}
+	return end + 1, nil
+}
+
+func (g *gcs) Delete(key string) error {
+	objectName := path.Join(g.prefix, key)
+	resp, err := g.do("DELETE", g.objectURL(objectName), nil, nil)
+	if err != nil {
+		return fmt.Errorf("unable to delete '%s': %s", objectName, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return FileNotFound{Key: key}
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("unable to delete '%s': %s", objectName, responseError(resp))
+	}
+
+	return nil
+}
+
+func (g *gcs) List() ([]string, error) {
+	prefix := g.prefix
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	results := []string{}
+	pageToken := ""
+	for {
+		query := url.Values{}
+		query.Set("prefix", prefix)
+		query.Set("fields", "items(name),nextPageToken")
+		if pageToken != "" {
+			query.Set("pageToken", pageToken)
+		}
+		listURL := fmt.Sprintf("%s/storage/v1/b/%s/o?%s", g.endpoint, url.PathEscape(g.bucket), query.Encode())
+
+		page, err := g.listPage(listURL)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list bucket '%s' with '%s': %s", g.bucket, g.prefix, err)
+		}
+		for _, item := range page.Items {
+			results = append(results, path.Base(item.Name))
+		}
+		if page.NextPageToken == "" {
+			return results, nil
+		}
+		pageToken = page.NextPageToken
+	}
+}
+
+func (g *gcs) listPage(listURL string) (gcsObjectList, error) {
+	resp, err := g.do("GET", listURL, nil, nil)
+	if err != nil {
+		return gcsObjectList{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return gcsObjectList{}, responseError(resp)
+	}
+	var page gcsObjectList
+	if err = json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return gcsObjectList{}, fmt.Errorf("failed to parse list response: %s", err)
+	}
+	return page, nil
+}
+
+func (g *gcs) objectURL(objectName string) string {
+	return fmt.Sprintf("%s/storage/v1/b/%s/o/%s", g.endpoint, url.PathEscape(g.bucket), url.PathEscape(objectName))
+}
+
+func (g *gcs) do(method string, requestURL string, body []byte, headers map[string]string) (*http.Response, error) {
+	req, err := http.NewRequest(method, requestURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	if g.key != nil {
+		token, err := g.accessToken()
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	return g.client.Do(req)
+}
+
+// accessToken exchanges a JWT signed by the service account key for
+// an OAuth2 access token, caching it until shortly before it expires.
+func (g *gcs) accessToken() (string, error) {
+	g.tokenMutex.Lock()
+	defer g.tokenMutex.Unlock()
+
+	now := time.Now()
+	if g.token != "" && now.Before(g.tokenExpiry) {
+		return g.token, nil
+	}
+
+	assertion, err := g.signJWT(now)
+	if err != nil {
+		return "", err
+	}
+	resp, err := g.client.PostForm(g.key.TokenURI, url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch access token: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to fetch access token: %s", responseError(resp))
+	}
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("failed to parse access token response: %s", err)
+	}
+
+	g.token = token.AccessToken
+	g.tokenExpiry = now.Add(time.Duration(token.ExpiresIn)*time.Second - time.Minute)
+	return g.token, nil
+}
+
+func (g *gcs) signJWT(now time.Time) (string, error) {
+	header, err := json.Marshal(map[string]string{
+		"alg": "RS256",
+		"typ": "JWT",
+		"kid": g.key.PrivateKeyID,
+	})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]interface{}{
+		"iss":   g.key.ClientEmail,
+		"scope": gcsScope,
+		"aud":   g.key.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	encoding := base64.RawURLEncoding
+	unsigned := encoding.EncodeToString(header) + "." + encoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, g.key.signer, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign access token request: %s", err)
+	}
+
+	return unsigned + "." + encoding.EncodeToString(signature), nil
+}
+
+func responseError(resp *http.Response) error {
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 4096))
+	return fmt.Errorf("unexpected status '%s': %s", resp.Status, strings.TrimSpace(string(body)))
+}
@@ -0,0 +1,397 @@
+package storage_test
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ljfranklin/test-runner-resource/storage"
+	"github.com/ljfranklin/test-runner-resource/test/helpers"
+)
+
+const fakeGCSToken = "some-access-token"
+
+// fakeGCS implements the subset of the GCS JSON API and OAuth2 token
+// endpoint used by the gcs storage backend.
+type fakeGCS struct {
+	t         *testing.T
+	publicKey *rsa.PublicKey
+	pageSize  int
+
+	mutex    sync.Mutex
+	objects  map[string][]byte
+	sessions map[string]*fakeUpload
+	// the next chunk PUT persists half its bytes and fails
+	failNextChunk bool
+	failedChunks  int
+	tokenRequests int
+}
+
+type fakeUpload struct {
+	name string
+	data []byte
+}
+
+func newFakeGCS(t *testing.T, publicKey *rsa.PublicKey) (*fakeGCS, *httptest.Server) {
+	fake := &fakeGCS{
+		t:         t,
+		publicKey: publicKey,
+		pageSize:  2,
+		objects:   map[string][]byte{},
+		sessions:  map[string]*fakeUpload{},
+	}
+	return fake, httptest.NewServer(fake)
+}
+
+func (f *fakeGCS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if r.URL.Path == "/token" {
+		f.serveToken(w, r)
+		return
+	}
+	if r.Header.Get("Authorization") != "Bearer "+fakeGCSToken {
+		http.Error(w, "invalid credentials", http.StatusUnauthorized)
+		return
+	}
+
+	switch {
+	case r.Method == "POST" && r.URL.Path == "/upload/storage/v1/b/some-bucket/o":
+		id := strconv.Itoa(len(f.sessions))
+		f.sessions[id] = &fakeUpload{name: r.URL.Query().Get("name")}
+		w.Header().Set("Location", fmt.Sprintf("http://%s/upload-session/%s", r.Host, id))
+	case r.Method == "PUT" && strings.HasPrefix(r.URL.Path, "/upload-session/"):
+		f.serveChunk(w, r, f.sessions[strings.TrimPrefix(r.URL.Path, "/upload-session/")])
+	case r.Method == "GET" && r.URL.Path == "/storage/v1/b/some-bucket/o":
+		f.serveList(w, r)
+	case strings.HasPrefix(r.URL.Path, "/storage/v1/b/some-bucket/o/"):
+		name := strings.TrimPrefix(r.URL.Path, "/storage/v1/b/some-bucket/o/")
+		contents, ok := f.objects[name]
+		if !ok {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		if r.Method == "DELETE" {
+			delete(f.objects, name)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Write(contents)
+	default:
+		http.Error(w, "unexpected request", http.StatusBadRequest)
+	}
+}
+
+func (f *fakeGCS) serveToken(w http.ResponseWriter, r *http.Request) {
+	f.tokenRequests++
+
+	parts := strings.Split(r.FormValue("assertion"), ".")
+	if len(parts) != 3 {
+		http.Error(w, "invalid assertion", http.StatusBadRequest)
+		return
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err = rsa.VerifyPKCS1v15(f.publicKey, crypto.SHA256, digest[:], signature); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token": fakeGCSToken,
+		"expires_in":   3600,
+	})
+}
+
+func (f *fakeGCS) serveChunk(w http.ResponseWriter, r *http.Request, upload *fakeUpload) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+
+	// e.g. "bytes 0-99/*", "bytes 100-149/150" or "bytes */150"
+	var start, end int
+	var total string
+	contentRange := r.Header.Get("Content-Range")
+	if strings.HasPrefix(contentRange, "bytes */") {
+		total = strings.TrimPrefix(contentRange, "bytes */")
+	} else if _, err := fmt.Sscanf(contentRange, "bytes %d-%d/%s", &start, &end, &total); err != nil {
+		http.Error(w, "invalid Content-Range", http.StatusBadRequest)
+		return
+	}
+
+	if len(body) > 0 {
+		if start != len(upload.data) {
+			http.Error(w, "unexpected offset", http.StatusBadRequest)
+			return
+		}
+		if f.failNextChunk {
+			f.failNextChunk = false
+			f.failedChunks++
+			upload.data = append(upload.data, body[:len(body)/2]...)
+			http.Error(w, "some-transient-error", http.StatusServiceUnavailable)
+			return
+		}
+		upload.data = append(upload.data, body...)
+	}
+
+	if total != "*" && total == strconv.Itoa(len(upload.data)) {
+		f.objects[upload.name] = upload.data
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if len(upload.data) > 0 {
+		w.Header().Set("Range", fmt.Sprintf("bytes=0-%d", len(upload.data)-1))
+	}
+	w.WriteHeader(http.StatusPermanentRedirect)
+}
+
+func (f *fakeGCS) serveList(w http.ResponseWriter, r *http.Request) {
+	names := []string{}
+	for name := range f.objects {
+		if strings.HasPrefix(name, r.URL.Query().Get("prefix")) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	offset := 0
+	if pageToken := r.URL.Query().Get("pageToken"); pageToken != "" {
+		offset, _ = strconv.Atoi(pageToken)
+	}
+	response := map[string]interface{}{}
+	items := []map[string]string{}
+	for i := offset; i < len(names) && i < offset+f.pageSize; i++ {
+		items = append(items, map[string]string{"name": names[i]})
+	}
+	response["items"] = items
+	if offset+f.pageSize < len(names) {
+		response["nextPageToken"] = strconv.Itoa(offset + f.pageSize)
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func buildGCSConfig(t *testing.T) (map[string]interface{}, *fakeGCS, func()) {
+	t.Helper()
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyBytes, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fake, server := newFakeGCS(t, &privateKey.PublicKey)
+	jsonKey, err := json.Marshal(map[string]string{
+		"type":           "service_account",
+		"client_email":   "some-account@some-project.iam.gserviceaccount.com",
+		"private_key_id": "some-key-id",
+		"private_key":    string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyBytes})),
+		"token_uri":      server.URL + "/token",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := map[string]interface{}{
+		"bucket":      "some-bucket",
+		"json_key":    string(jsonKey),
+		"endpoint":    server.URL,
+		"path_prefix": "results",
+	}
+	return config, fake, server.Close
+}
+
+func TestGCSPutAndGet(t *testing.T) {
+	t.Parallel()
+
+	config, fake, cleanup := buildGCSConfig(t)
+	defer cleanup()
+
+	gcs, err := storage.New("gcs", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fixture, err := ioutil.ReadFile(fixturePath("some-file"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = gcs.Put("some-key", bytes.NewReader(fixture)); err != nil {
+		t.Fatal(err)
+	}
+	helpers.AssertEquals(t, string(fake.objects["results/some-key"]), "some-file-contents\n")
+
+	fileContents := bytes.Buffer{}
+	if err = gcs.Get("some-key", &fileContents); err != nil {
+		t.Fatal(err)
+	}
+	helpers.AssertEquals(t, fileContents.String(), "some-file-contents\n")
+
+	// the access token is cached between requests
+	helpers.AssertEquals(t, fake.tokenRequests, 1)
+
+	err = gcs.Get("key-that-does-not-exist", &fileContents)
+	if _, ok := err.(storage.FileNotFound); !ok {
+		t.Fatalf("expected FileNotFound error but got: %v", err)
+	}
+}
+
+func TestGCSPutResumesChunkedUpload(t *testing.T) {
+	t.Parallel()
+
+	config, fake, cleanup := buildGCSConfig(t)
+	defer cleanup()
+	config["chunk_size"] = float64(256 * 1024)
+
+	gcs, err := storage.New("gcs", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	contents := make([]byte, 600*1024)
+	if _, err = rand.Read(contents); err != nil {
+		t.Fatal(err)
+	}
+	fake.failNextChunk = true
+
+	if err = gcs.Put("some-large-key", bytes.NewReader(contents)); err != nil {
+		t.Fatal(err)
+	}
+	helpers.AssertEquals(t, fake.failedChunks, 1)
+	if !bytes.Equal(fake.objects["results/some-large-key"], contents) {
+		t.Fatal("expected uploaded object to match contents but it did not")
+	}
+}
+
+func TestGCSPutEmptyFile(t *testing.T) {
+	t.Parallel()
+
+	config, fake, cleanup := buildGCSConfig(t)
+	defer cleanup()
+
+	gcs, err := storage.New("gcs", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = gcs.Put("some-empty-key", bytes.NewReader(nil)); err != nil {
+		t.Fatal(err)
+	}
+	contents, ok := fake.objects["results/some-empty-key"]
+	if !ok {
+		t.Fatal("expected empty object to be uploaded but it was not")
+	}
+	helpers.AssertEquals(t, len(contents), 0)
+}
+
+func TestGCSPutErrorOnInvalidInputFile(t *testing.T) {
+	t.Parallel()
+
+	config, _, cleanup := buildGCSConfig(t)
+	defer cleanup()
+
+	gcs, err := storage.New("gcs", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = gcs.Put("some-upload-path", errReader{})
+	if err == nil {
+		t.Fatal("expected an error on invalid reader")
+	}
+	if !strings.Contains(err.Error(), "some-read-error") {
+		t.Fatalf("expected '%s' to contain 'some-read-error'", err.Error())
+	}
+}
+
+func TestGCSDelete(t *testing.T) {
+	t.Parallel()
+
+	config, fake, cleanup := buildGCSConfig(t)
+	defer cleanup()
+	fake.objects["results/some-key"] = []byte("some-contents")
+
+	gcs, err := storage.New("gcs", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = gcs.Delete("some-key"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := fake.objects["results/some-key"]; ok {
+		t.Fatal("expected object to be deleted but it was not")
+	}
+
+	err = gcs.Delete("some-key")
+	if _, ok := err.(storage.FileNotFound); !ok {
+		t.Fatalf("expected FileNotFound error but got: %v", err)
+	}
+}
+
+func TestGCSListPaginates(t *testing.T) {
+	t.Parallel()
+
+	config, fake, cleanup := buildGCSConfig(t)
+	defer cleanup()
+	for _, name := range []string{"results/key-a", "results/key-b", "results/key-c", "other/key-d"} {
+		fake.objects[name] = []byte(name)
+	}
+
+	gcs, err := storage.New("gcs", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := gcs.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	helpers.AssertEquals(t, results, []string{"key-a", "key-b", "key-c"})
+}
+
+func TestGCSErrorOnInvalidConfig(t *testing.T) {
+	t.Parallel()
+
+	_, err := storage.New("gcs", map[string]interface{}{})
+	if err == nil {
+		t.Fatal("expected error on missing config but none occurred")
+	}
+	if !strings.Contains(err.Error(), "bucket, json_key") {
+		t.Fatalf("expected error to contain 'bucket, json_key' but it did not: %s", err)
+	}
+
+	_, err = storage.New("gcs", map[string]interface{}{
+		"bucket":   "some-bucket",
+		"json_key": `{"client_email": "some-email", "private_key": "not-a-key"}`,
+	})
+	if err == nil {
+		t.Fatal("expected error on invalid key but none occurred")
+	}
+	if !strings.Contains(err.Error(), "private_key") {
+		t.Fatalf("expected error to contain 'private_key' but it did not: %s", err)
+	}
+}
@@ -0,0 +1,317 @@
+package storage
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const (
+	maxPushAttempts = 10
+	gitCommitter    = "test-runner-resource"
+	// the repo may hold other files, e.g. a README next to the results
+	gitResultsPattern = "test-results-*.xml"
+)
+
+type git struct {
+	uri        string
+	branch     string
+	privateKey string
+	prefix     string
+
+	// the clone is created on first use and reused afterwards,
+	// Close removes it along with the private key
+	mutex   sync.Mutex
+	repoDir string
+	keyDir  string
+}
+
+func ValidateGitConfig(config map[string]interface{}) error {
+	requiredProps := []string{
+		"uri",
+		"branch",
+	}
+
+	missingProps := []string{}
+	for _, required := range requiredProps {
+		if _, ok := config[required]; !ok {
+			missingProps = append(missingProps, required)
+		}
+	}
+
+	if len(missingProps) > 0 {
+		return fmt.Errorf("missing required properties in storage_config: %s", strings.Join(missingProps, ", "))
+	}
+	return nil
+}
+
+func NewGit(config map[string]interface{}) Storage {
+	g := &git{
+		uri:    config["uri"].(string),
+		branch: config["branch"].(string),
+	}
+	if privateKey, ok := config["private_key"]; ok {
+		g.privateKey = privateKey.(string)
+	}
+	if prefix, ok := config["path_prefix"]; ok {
+		g.prefix = prefix.(string)
+	}
+
+	return g
+}
+
+// Get reads from the existing clone, e.g. the one made by List, and
+// only fetches from the remote if the key is missing. Results are
+// never modified once pushed so the clone cannot be stale.
+func (g *git) Get(key string, destination io.Writer) error {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	path := filepath.Join(g.prefix, key)
+	synced := false
+	if g.repoDir == "" {
+		if err := g.sync(); err != nil {
+			return err
+		}
+		synced = true
+	}
+
+	f, err := os.Open(filepath.Join(g.repoDir, path))
+	if os.IsNotExist(err) && !synced {
+		if err = g.sync(); err != nil {
+			return err
+		}
+		f, err = os.Open(filepath.Join(g.repoDir, path))
+	}
+	if os.IsNotExist(err) {
+		return FileNotFound{Key: key}
+	}
+	if err != nil {
+		return fmt.Errorf("unable to fetch '%s': %s", key, err)
+	}
+	defer f.Close()
+
+	_, err = io.Copy(destination, f)
+	if err != nil {
+		return fmt.Errorf("failed to copy download to local file: %s", err)
+	}
+
+	return nil
+}
+
+func (g *git) Put(key string, source io.Reader) error {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	contents, err := ioutil.ReadAll(source)
+	if err != nil {
+		return fmt.Errorf("unable to upload '%s': %s", key, err)
+	}
+
+	if err = g.sync(); err != nil {
+		return err
+	}
+
+	path := filepath.Join(g.prefix, key)
+	if err = os.MkdirAll(filepath.Dir(filepath.Join(g.repoDir, path)), 0755); err != nil {
+		return fmt.Errorf("unable to upload '%s': %s", key, err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(g.repoDir, path), contents, 0644); err != nil {
+		return fmt.Errorf("unable to upload '%s': %s", key, err)
+	}
+	if _, err = g.run("add", "--", path); err != nil {
+		return err
+	}
+	if _, err = g.run("commit", "--message", fmt.Sprintf("Add test results %s", key)); err != nil {
+		return err
+	}
+
+	return g.push()
+}
+
+func (g *git) Delete(key string) error {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if err := g.sync(); err != nil {
+		return err
+	}
+
+	path := filepath.Join(g.prefix, key)
+	if _, err := os.Stat(filepath.Join(g.repoDir, path)); os.IsNotExist(err) {
+		return FileNotFound{Key: key}
+	}
+	if _, err := g.run("rm", "--quiet", "--", path); err != nil {
+		return err
+	}
+	if _, err := g.run("commit", "--message", fmt.Sprintf("Remove test results %s", key)); err != nil {
+		return err
+	}
+
+	return g.push()
+}
+
+func (g *git) List() ([]string, error) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if err := g.sync(); err != nil {
+		return nil, err
+	}
+
+	files, err := ioutil.ReadDir(filepath.Join(g.repoDir, g.prefix))
+	if os.IsNotExist(err) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to list '%s' with '%s': %s", g.uri, g.prefix, err)
+	}
+
+	results := []string{}
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		if matched, _ := filepath.Match(gitResultsPattern, file.Name()); !matched {
+			continue
+		}
+		results = append(results, file.Name())
+	}
+
+	return results, nil
+}
+
+// sync clones the repo on first use and otherwise resets the local
+// branch to match the remote.
+func (g *git) sync() error {
+	if g.repoDir == "" {
+		if err := g.init(); err != nil {
+			return err
+		}
+	}
+
+	exists, err := g.remoteBranchExists()
+	if err != nil {
+		return err
+	}
+	if !exists {
+		// the first Put will create the branch
+		return nil
+	}
+
+	if _, err = g.run("fetch", "--quiet", "origin", g.branch); err != nil {
+		return err
+	}
+	_, err = g.run("reset", "--quiet", "--hard", "FETCH_HEAD")
+	return err
+}
+
+func (g *git) init() error {
+	repoDir, err := ioutil.TempDir("", "git-storage")
+	if err != nil {
+		return err
+	}
+	g.repoDir = repoDir
+
+	if g.privateKey != "" {
+		// the directory is only readable by the current user
+		keyDir, err := ioutil.TempDir("", "git-storage-key")
+		if err != nil {
+			return err
+		}
+		g.keyDir = keyDir
+		err = ioutil.WriteFile(g.keyPath(), []byte(strings.TrimSpace(g.privateKey)+"\n"), 0600)
+		if err != nil {
+			return err
+		}
+	}
+
+	if _, err = g.run("init", "--quiet"); err != nil {
+		return err
+	}
+	if _, err = g.run("checkout", "--quiet", "-b", g.branch); err != nil {
+		return err
+	}
+	_, err = g.run("remote", "add", "origin", g.uri)
+	return err
+}
+
+// Close removes the clone and private key from disk. The next call
+// to any other method clones the repo again.
+func (g *git) Close() error {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	for _, dir := range []string{g.repoDir, g.keyDir} {
+		if dir == "" {
+			continue
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			return fmt.Errorf("unable to remove '%s': %s", dir, err)
+		}
+	}
+	g.repoDir = ""
+	g.keyDir = ""
+	return nil
+}
+
+func (g *git) keyPath() string {
+	return filepath.Join(g.keyDir, "id")
+}
+
+func (g *git) remoteBranchExists() (bool, error) {
+	output, err := g.run("ls-remote", "--heads", "origin", g.branch)
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(output) != "", nil
+}
+
+// push retries when parallel builds race to push to the same branch,
+// rebasing the local commit onto the latest remote commit each time.
+func (g *git) push() error {
+	var err error
+	for attempt := 0; attempt < maxPushAttempts; attempt++ {
+		if _, err = g.run("push", "--quiet", "origin", fmt.Sprintf("HEAD:refs/heads/%s", g.branch)); err == nil {
+			return nil
+		}
+		if _, fetchErr := g.run("fetch", "--quiet", "origin", g.branch); fetchErr != nil {
+			return fetchErr
+		}
+		if _, rebaseErr := g.run("rebase", "--quiet", "FETCH_HEAD"); rebaseErr != nil {
+			g.run("rebase", "--abort")
+			return rebaseErr
+		}
+	}
+
+	return fmt.Errorf("failed to push to '%s' after %d attempts: %s", g.uri, maxPushAttempts, err)
+}
+
+func (g *git) run(args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = g.repoDir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME="+gitCommitter,
+		"GIT_AUTHOR_EMAIL="+gitCommitter+"@localhost",
+		"GIT_COMMITTER_NAME="+gitCommitter,
+		"GIT_COMMITTER_EMAIL="+gitCommitter+"@localhost",
+		"GIT_TERMINAL_PROMPT=0",
+	)
+	if g.keyDir != "" {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("GIT_SSH_COMMAND=ssh -i %s -o IdentitiesOnly=yes -o StrictHostKeyChecking=no -o UserKnownHostsFile=/dev/null", g.keyPath()))
+	}
+
+	output := bytes.Buffer{}
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s failed: %s: %s", args[0], err, strings.TrimSpace(output.String()))
+	}
+	return output.String(), nil
+}
@@ -0,0 +1,334 @@
+package storage_test
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ljfranklin/test-runner-resource/storage"
+	"github.com/ljfranklin/test-runner-resource/test/helpers"
+)
+
+func createBareRepo(t *testing.T) string {
+	t.Helper()
+
+	repoDir, err := ioutil.TempDir("", "git-storage-remote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, err := exec.Command("git", "init", "--quiet", "--bare", repoDir).CombinedOutput()
+	if err != nil {
+		t.Fatalf("failed to create bare repo: %s: %s", err, output)
+	}
+	return repoDir
+}
+
+func buildGitConfig(repoDir string) map[string]interface{} {
+	return map[string]interface{}{
+		"uri":         repoDir,
+		"branch":      "test-results",
+		"path_prefix": "results",
+	}
+}
+
+func TestGitPutAndGet(t *testing.T) {
+	t.Parallel()
+
+	repoDir := createBareRepo(t)
+	defer os.RemoveAll(repoDir)
+
+	uploader, err := storage.New("git", buildGitConfig(repoDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fixture, err := os.Open(fixturePath("some-file"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fixture.Close()
+
+	if err = uploader.Put("some-key", fixture); err != nil {
+		t.Fatal(err)
+	}
+
+	// a separate instance ensures the file was pushed
+	downloader, err := storage.New("git", buildGitConfig(repoDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fileContents := bytes.Buffer{}
+	if err = downloader.Get("some-key", &fileContents); err != nil {
+		t.Fatal(err)
+	}
+	helpers.AssertEquals(t, fileContents.String(), "some-file-contents\n")
+
+	output, err := exec.Command("git", "--git-dir", repoDir, "ls-tree", "-r", "--name-only", "test-results").CombinedOutput()
+	if err != nil {
+		t.Fatalf("failed to list remote branch: %s: %s", err, output)
+	}
+	helpers.AssertEquals(t, strings.TrimSpace(string(output)), "results/some-key")
+}
+
+func TestGitGetErrorOnMissingKey(t *testing.T) {
+	t.Parallel()
+
+	repoDir := createBareRepo(t)
+	defer os.RemoveAll(repoDir)
+
+	git, err := storage.New("git", buildGitConfig(repoDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = git.Get("key-that-does-not-exist", &bytes.Buffer{})
+	if err == nil {
+		t.Fatal("expected error to occur on missing file")
+	}
+	if _, ok := err.(storage.FileNotFound); !ok {
+		t.Fatalf("expected FileNotFound error but got: %s", err)
+	}
+}
+
+func TestGitPutErrorOnInvalidInputFile(t *testing.T) {
+	t.Parallel()
+
+	repoDir := createBareRepo(t)
+	defer os.RemoveAll(repoDir)
+
+	git, err := storage.New("git", buildGitConfig(repoDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = git.Put("some-upload-path", errReader{})
+	if err == nil {
+		t.Fatal("expected an error on invalid reader")
+	}
+	if !strings.Contains(err.Error(), "some-read-error") {
+		t.Fatalf("expected '%s' to contain 'some-read-error'", err.Error())
+	}
+}
+
+func TestGitDelete(t *testing.T) {
+	t.Parallel()
+
+	repoDir := createBareRepo(t)
+	defer os.RemoveAll(repoDir)
+
+	git, err := storage.New("git", buildGitConfig(repoDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = git.Put("test-results-2018-01-01T00:00:00Z.xml", strings.NewReader("some-contents")); err != nil {
+		t.Fatal(err)
+	}
+	if err = git.Delete("test-results-2018-01-01T00:00:00Z.xml"); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := git.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	helpers.AssertEquals(t, results, []string{})
+
+	err = git.Delete("test-results-2018-01-01T00:00:00Z.xml")
+	if _, ok := err.(storage.FileNotFound); !ok {
+		t.Fatalf("expected FileNotFound error but got: %v", err)
+	}
+}
+
+func TestGitList(t *testing.T) {
+	t.Parallel()
+
+	repoDir := createBareRepo(t)
+	defer os.RemoveAll(repoDir)
+
+	git, err := storage.New("git", buildGitConfig(repoDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := git.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	helpers.AssertEquals(t, results, []string{})
+
+	keys := []string{
+		"README.md",
+		"test-results-2018-01-02T00:00:00Z.xml",
+		"test-results-2018-01-01T00:00:00Z.xml",
+	}
+	for _, key := range keys {
+		if err = git.Put(key, strings.NewReader(key)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	results, err = git.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	helpers.AssertEquals(t, results, []string{
+		"test-results-2018-01-01T00:00:00Z.xml",
+		"test-results-2018-01-02T00:00:00Z.xml",
+	})
+}
+
+func TestGitGetUsesExistingClone(t *testing.T) {
+	t.Parallel()
+
+	repoDir := createBareRepo(t)
+	defer os.RemoveAll(repoDir)
+
+	uploader, err := storage.New("git", buildGitConfig(repoDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = uploader.Put("test-results-2018-01-01T00:00:00Z.xml", strings.NewReader("contents-a")); err != nil {
+		t.Fatal(err)
+	}
+
+	downloader, err := storage.New("git", buildGitConfig(repoDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer storage.Close(downloader)
+	results, err := downloader.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	helpers.AssertEquals(t, results, []string{"test-results-2018-01-01T00:00:00Z.xml"})
+
+	// keys added after List are fetched on demand
+	if err = uploader.Put("test-results-2018-01-02T00:00:00Z.xml", strings.NewReader("contents-b")); err != nil {
+		t.Fatal(err)
+	}
+	contents := bytes.Buffer{}
+	if err = downloader.Get("test-results-2018-01-02T00:00:00Z.xml", &contents); err != nil {
+		t.Fatal(err)
+	}
+	helpers.AssertEquals(t, contents.String(), "contents-b")
+
+	// keys already in the clone are read without contacting the remote
+	if err = os.RemoveAll(repoDir); err != nil {
+		t.Fatal(err)
+	}
+	contents.Reset()
+	if err = downloader.Get("test-results-2018-01-01T00:00:00Z.xml", &contents); err != nil {
+		t.Fatal(err)
+	}
+	helpers.AssertEquals(t, contents.String(), "contents-a")
+}
+
+func TestGitConcurrentPuts(t *testing.T) {
+	t.Parallel()
+
+	repoDir := createBareRepo(t)
+	defer os.RemoveAll(repoDir)
+
+	expectedKeys := []string{}
+	errs := make(chan error, 5)
+	wg := sync.WaitGroup{}
+	for i := 0; i < 5; i++ {
+		key := fmt.Sprintf("test-results-2018-01-0%dT00:00:00Z.xml", i+1)
+		expectedKeys = append(expectedKeys, key)
+
+		// each instance has its own clone, as with parallel builds
+		git, err := storage.New("git", buildGitConfig(repoDir))
+		if err != nil {
+			t.Fatal(err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- git.Put(key, strings.NewReader(key))
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	git, err := storage.New("git", buildGitConfig(repoDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := git.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(results)
+	helpers.AssertEquals(t, results, expectedKeys)
+}
+
+func TestGitCloseRemovesCloneAndKey(t *testing.T) {
+	repoDir := createBareRepo(t)
+	defer os.RemoveAll(repoDir)
+
+	// not parallel as the clone location is read from $TMPDIR
+	tmpDir, err := ioutil.TempDir("", "git-storage-tmp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	originalTmpDir := os.Getenv("TMPDIR")
+	os.Setenv("TMPDIR", tmpDir)
+	defer os.Setenv("TMPDIR", originalTmpDir)
+
+	config := buildGitConfig(repoDir)
+	config["private_key"] = "some-private-key"
+	git, err := storage.New("git", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = git.Put("some-key", strings.NewReader("some-contents")); err != nil {
+		t.Fatal(err)
+	}
+
+	keyPaths, err := filepath.Glob(filepath.Join(tmpDir, "git-storage-key*", "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	helpers.AssertEquals(t, len(keyPaths), 1)
+	info, err := os.Stat(keyPaths[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	helpers.AssertEquals(t, info.Mode().Perm(), os.FileMode(0600))
+
+	if err = storage.Close(git); err != nil {
+		t.Fatal(err)
+	}
+	remaining, err := ioutil.ReadDir(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	helpers.AssertEquals(t, len(remaining), 0)
+}
+
+func TestGitErrorOnMissingConfig(t *testing.T) {
+	t.Parallel()
+
+	_, err := storage.New("git", map[string]interface{}{})
+	if err == nil {
+		t.Fatal("expected error on missing config but none occurred")
+	}
+	if !strings.Contains(err.Error(), "uri, branch") {
+		t.Fatalf("expected error to contain 'uri, branch' but it did not: %s", err)
+	}
+}
@@ -0,0 +1,185 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+var labelRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// multi aggregates several labelled storages. Keys are namespaced as
+// "<label>/<key>" so results from different storages never collide.
+type multi struct {
+	labels   []string
+	storages map[string]Storage
+}
+
+func ValidateMultiConfig(config map[string]interface{}) error {
+	entries, ok := config["storages"].([]interface{})
+	if !ok || len(entries) == 0 {
+		return errors.New("missing required properties in storage_config: storages")
+	}
+
+	seen := map[string]bool{}
+	for i, entry := range entries {
+		label, storageType, storageConfig, err := parseMultiEntry(entry)
+		if err != nil {
+			return fmt.Errorf("invalid storages[%d]: %s", i, err)
+		}
+		if !labelRegex.MatchString(label) {
+			return fmt.Errorf("invalid storages[%d]: label '%s' must only contain letters, digits, '_', '.' or '-'", i, label)
+		}
+		if seen[label] {
+			return fmt.Errorf("invalid storages[%d]: duplicate label '%s'", i, label)
+		}
+		seen[label] = true
+		if storageType == "multi" {
+			return fmt.Errorf("invalid storages[%d]: multi storages cannot be nested", i)
+		}
+		if _, err := New(storageType, storageConfig); err != nil {
+			return fmt.Errorf("invalid storages[%d] '%s': %s", i, label, err)
+		}
+	}
+	return nil
+}
+
+func NewMulti(config map[string]interface{}) Storage {
+	m := &multi{
+		storages: map[string]Storage{},
+	}
+	for _, entry := range config["storages"].([]interface{}) {
+		// already checked by ValidateMultiConfig
+		label, storageType, storageConfig, _ := parseMultiEntry(entry)
+		m.labels = append(m.labels, label)
+		m.storages[label], _ = New(storageType, storageConfig)
+	}
+	return m
+}
+
+// Labels returns the configured labels of a multi storage, or nil
+// for any other storage.
+func Labels(s Storage) []string {
+	m, ok := s.(*multi)
+	if !ok {
+		return nil
+	}
+	return m.labels
+}
+
+func parseMultiEntry(entry interface{}) (string, string, map[string]interface{}, error) {
+	fields, ok := entry.(map[string]interface{})
+	if !ok {
+		return "", "", nil, errors.New("must contain label, storage_type and storage_config")
+	}
+
+	missingProps := []string{}
+	label, ok := fields["label"].(string)
+	if !ok {
+		missingProps = append(missingProps, "label")
+	}
+	storageType, ok := fields["storage_type"].(string)
+	if !ok {
+		missingProps = append(missingProps, "storage_type")
+	}
+	storageConfig, ok := fields["storage_config"].(map[string]interface{})
+	if !ok {
+		missingProps = append(missingProps, "storage_config")
+	}
+	if len(missingProps) > 0 {
+		return "", "", nil, fmt.Errorf("missing required properties: %s", strings.Join(missingProps, ", "))
+	}
+
+	return label, storageType, storageConfig, nil
+}
+
+func (m *multi) Get(key string, destination io.Writer) error {
+	storage, innerKey, err := m.route(key)
+	if err != nil {
+		return err
+	}
+	if err = storage.Get(innerKey, destination); err != nil {
+		if _, ok := err.(FileNotFound); ok {
+			return FileNotFound{Key: key}
+		}
+		return err
+	}
+	return nil
+}
+
+func (m *multi) Put(key string, source io.Reader) error {
+	storage, innerKey, err := m.route(key)
+	if err != nil {
+		return err
+	}
+	return storage.Put(innerKey, source)
+}
+
+func (m *multi) Delete(key string) error {
+	storage, innerKey, err := m.route(key)
+	if err != nil {
+		return err
+	}
+	if err = storage.Delete(innerKey); err != nil {
+		if _, ok := err.(FileNotFound); ok {
+			return FileNotFound{Key: key}
+		}
+		return err
+	}
+	return nil
+}
+
+// List queries every storage in parallel and returns the namespaced
+// keys grouped in the order the storages were configured.
+func (m *multi) List() ([]string, error) {
+	keys := make([][]string, len(m.labels))
+	errs := make([]error, len(m.labels))
+
+	var wg sync.WaitGroup
+	for i, label := range m.labels {
+		wg.Add(1)
+		go func(i int, label string) {
+			defer wg.Done()
+			keys[i], errs[i] = m.storages[label].List()
+		}(i, label)
+	}
+	wg.Wait()
+
+	results := []string{}
+	for i, label := range m.labels {
+		if errs[i] != nil {
+			return nil, fmt.Errorf("unable to list storage '%s': %s", label, errs[i])
+		}
+		for _, key := range keys[i] {
+			results = append(results, label+"/"+key)
+		}
+	}
+
+	return results, nil
+}
+
+// Close closes every storage, returning the first error.
+func (m *multi) Close() error {
+	var firstErr error
+	for _, label := range m.labels {
+		if err := Close(m.storages[label]); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("unable to close storage '%s': %s", label, err)
+		}
+	}
+	return firstErr
+}
+
+func (m *multi) route(key string) (Storage, string, error) {
+	parts := strings.SplitN(key, "/", 2)
+	if len(parts) != 2 {
+		return nil, "", fmt.Errorf("key '%s' must be prefixed with a storage label, e.g. '%s/%s'", key, m.labels[0], key)
+	}
+	storage, ok := m.storages[parts[0]]
+	if !ok {
+		return nil, "", fmt.Errorf("key '%s' does not match any storage label; expected one of: %s", key, strings.Join(m.labels, ", "))
+	}
+	return storage, parts[1], nil
+}
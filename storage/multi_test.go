@@ -0,0 +1,177 @@
+package storage_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ljfranklin/test-runner-resource/storage"
+	"github.com/ljfranklin/test-runner-resource/test/helpers"
+)
+
+func buildMultiConfig(rootDir string, labels ...string) map[string]interface{} {
+	storages := []interface{}{}
+	for _, label := range labels {
+		storages = append(storages, map[string]interface{}{
+			"label":        label,
+			"storage_type": "filesystem",
+			"storage_config": map[string]interface{}{
+				"path": filepath.Join(rootDir, label),
+			},
+		})
+	}
+	return map[string]interface{}{
+		"storages": storages,
+	}
+}
+
+func TestMultiListAndGet(t *testing.T) {
+	t.Parallel()
+
+	rootDir, err := ioutil.TempDir("", "multi-storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(rootDir)
+
+	for _, label := range []string{"team-a", "team-b"} {
+		if err = os.MkdirAll(filepath.Join(rootDir, label), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err = ioutil.WriteFile(filepath.Join(rootDir, label, "some-key"), []byte(label+"-contents"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	multi, err := storage.New("multi", buildMultiConfig(rootDir, "team-a", "team-b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := multi.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	helpers.AssertEquals(t, results, []string{"team-a/some-key", "team-b/some-key"})
+
+	fileContents := bytes.Buffer{}
+	if err = multi.Get("team-b/some-key", &fileContents); err != nil {
+		t.Fatal(err)
+	}
+	helpers.AssertEquals(t, fileContents.String(), "team-b-contents")
+
+	err = multi.Get("team-a/key-that-does-not-exist", &fileContents)
+	if notFound, ok := err.(storage.FileNotFound); !ok || notFound.Key != "team-a/key-that-does-not-exist" {
+		t.Fatalf("expected FileNotFound error but got: %v", err)
+	}
+}
+
+func TestMultiPutAndDeleteRouteByLabel(t *testing.T) {
+	t.Parallel()
+
+	rootDir, err := ioutil.TempDir("", "multi-storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(rootDir)
+
+	multi, err := storage.New("multi", buildMultiConfig(rootDir, "team-a", "team-b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = multi.Put("team-b/some-key", strings.NewReader("some-contents")); err != nil {
+		t.Fatal(err)
+	}
+	contents, err := ioutil.ReadFile(filepath.Join(rootDir, "team-b", "some-key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	helpers.AssertEquals(t, string(contents), "some-contents")
+
+	if err = multi.Delete("team-b/some-key"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(filepath.Join(rootDir, "team-b", "some-key")); !os.IsNotExist(err) {
+		t.Fatalf("expected file to be deleted but got: %v", err)
+	}
+
+	err = multi.Put("some-key", strings.NewReader("some-contents"))
+	if err == nil {
+		t.Fatal("expected an error on key without a label")
+	}
+	if !strings.Contains(err.Error(), "team-a/some-key") {
+		t.Fatalf("expected '%s' to contain 'team-a/some-key'", err.Error())
+	}
+
+	err = multi.Put("team-c/some-key", strings.NewReader("some-contents"))
+	if err == nil {
+		t.Fatal("expected an error on unknown label")
+	}
+	if !strings.Contains(err.Error(), "team-a, team-b") {
+		t.Fatalf("expected '%s' to contain 'team-a, team-b'", err.Error())
+	}
+}
+
+func TestMultiErrorOnInvalidConfig(t *testing.T) {
+	t.Parallel()
+
+	for _, testCase := range []struct {
+		config   map[string]interface{}
+		expected string
+	}{
+		{
+			config:   map[string]interface{}{},
+			expected: "storages",
+		},
+		{
+			config: map[string]interface{}{
+				"storages": []interface{}{map[string]interface{}{}},
+			},
+			expected: "label, storage_type, storage_config",
+		},
+		{
+			config:   buildMultiConfig("/some/root", "team-a", "team-a"),
+			expected: "duplicate label 'team-a'",
+		},
+		{
+			config:   buildMultiConfig("/some/root", "team/a"),
+			expected: "label 'team/a'",
+		},
+		{
+			config: map[string]interface{}{
+				"storages": []interface{}{
+					map[string]interface{}{
+						"label":          "team-a",
+						"storage_type":   "multi",
+						"storage_config": buildMultiConfig("/some/root", "team-b"),
+					},
+				},
+			},
+			expected: "cannot be nested",
+		},
+		{
+			config: map[string]interface{}{
+				"storages": []interface{}{
+					map[string]interface{}{
+						"label":          "team-a",
+						"storage_type":   "filesystem",
+						"storage_config": map[string]interface{}{},
+					},
+				},
+			},
+			expected: "'team-a': missing required properties in storage_config: path",
+		},
+	} {
+		_, err := storage.New("multi", testCase.config)
+		if err == nil {
+			t.Fatalf("expected error for config %v but none occurred", testCase.config)
+		}
+		if !strings.Contains(err.Error(), testCase.expected) {
+			t.Fatalf("expected error to contain '%s' but it did not: %s", testCase.expected, err)
+		}
+	}
+}
@@ -91,7 +91,8 @@ func NewS3(config map[string]interface{}) Storage {
 	}
 	s3.uploader = s3manager.NewUploaderWithClient(s3.client)
 	if s3.isGCSHost() {
-		// GCS returns `InvalidArgument` on multipart uploads
+		// GCS returns `InvalidArgument` on multipart uploads,
+		// storage_type: gcs avoids the S3 interop API entirely
 		s3.uploader.MaxUploadParts = 1
 	}
 
@@ -22,14 +22,54 @@ type Storage interface {
 	List() ([]string, error)
 }
 
+// Close releases any local state held by s, such as the clone made by
+// git storage. Storages without local state implement no Close method.
+func Close(s Storage) error {
+	if closer, ok := s.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
 func New(configType string, config map[string]interface{}) (Storage, error) {
+	if disabled, ok := config["disabled"].(bool); ok && disabled {
+		return NewDisabled(), nil
+	}
+
 	switch configType {
 	case "s3":
 		if err := ValidateS3Config(config); err != nil {
 			return nil, err
 		}
 		return NewS3(config), nil
+	case "git":
+		if err := ValidateGitConfig(config); err != nil {
+			return nil, err
+		}
+		return NewGit(config), nil
+	case "filesystem":
+		if err := ValidateFilesystemConfig(config); err != nil {
+			return nil, err
+		}
+		return NewFilesystem(config), nil
+	case "gcs":
+		if err := ValidateGCSConfig(config); err != nil {
+			return nil, err
+		}
+		return NewGCS(config), nil
+	case "azure":
+		if err := ValidateAzureConfig(config); err != nil {
+			return nil, err
+		}
+		return NewAzure(config), nil
+	case "multi":
+		if err := ValidateMultiConfig(config); err != nil {
+			return nil, err
+		}
+		return NewMulti(config), nil
+	case "disabled":
+		return NewDisabled(), nil
 	default:
-		return nil, fmt.Errorf("unrecognized storage_type '%s'; set storage_type to one of the following: 's3'", configType)
+		return nil, fmt.Errorf("unrecognized storage_type '%s'; set storage_type to one of the following: 's3', 'gcs', 'azure', 'git', 'filesystem', 'multi', 'disabled'", configType)
 	}
 }
@@ -0,0 +1,337 @@
+// Package tap converts Test Anything Protocol output, see
+// https://testanything.org/tap-version-14-specification.html,
+// into the JUnit model stored by the resource.
+package tap
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ljfranklin/test-runner-resource/junit"
+	yaml "gopkg.in/yaml.v2"
+)
+
+var (
+	planRegex = regexp.MustCompile(`^1\.\.(\d+)\s*(?:#\s*(.*))?$`)
+	testRegex = regexp.MustCompile(`^(not )?ok(?:\s|$)\s*(\d+)?\s*(?:-\s*)?(.*)$`)
+	// e.g. "# SKIP no database" or "# TODO not implemented"
+	directiveRegex = regexp.MustCompile(`(?i)^(skip|todo)\S*\s*(.*)$`)
+)
+
+// subtests are indented by four spaces, YAML diagnostics by two
+const subtestIndent = 4
+
+type parser struct {
+	lines  []string
+	pos    int
+	bailed bool
+	// set once a plan or test line is found
+	foundTAP bool
+}
+
+// testPoint is the test case being built from the most recent test
+// line, along with any output which followed it.
+type testPoint struct {
+	testCase junit.TestCase
+	output   []string
+	// set for `not ok` lines which are neither skipped nor todo
+	failed bool
+}
+
+// ParseFile names the suite after the file, e.g. "login" for "login.tap".
+func ParseFile(path string) (junit.TestSuites, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return junit.TestSuites{}, err
+	}
+	defer f.Close()
+
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	suites, err := Parse(f, name)
+	if err != nil {
+		return junit.TestSuites{}, fmt.Errorf("failed to parse '%s': %s", path, err)
+	}
+	return suites, nil
+}
+
+// Parse reads a TAP stream into a single suite named name, with a
+// nested suite for each subtest.
+func Parse(r io.Reader, name string) (junit.TestSuites, error) {
+	lines := []string{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, strings.TrimRight(strings.Replace(scanner.Text(), "\t", "    ", -1), "\r "))
+	}
+	if err := scanner.Err(); err != nil {
+		return junit.TestSuites{}, fmt.Errorf("unable to read TAP: %s", err)
+	}
+
+	p := &parser{lines: lines}
+	suite := p.parseSuite(name, 0)
+	finalize(&suite)
+	if !p.foundTAP {
+		return junit.TestSuites{}, fmt.Errorf("found no TAP plan or test lines")
+	}
+
+	suites := junit.Merge([]junit.TestSuites{
+		{TestSuites: []junit.TestSuite{suite}},
+	})
+	suites.XMLName = xml.Name{Local: "testsuites"}
+	return suites, nil
+}
+
+// parseSuite consumes lines until one is indented less than indent.
+func (p *parser) parseSuite(name string, indent int) junit.TestSuite {
+	suite := junit.TestSuite{
+		Name: name,
+	}
+	planned := -1
+	subtestName := ""
+	var subtest *junit.TestSuite
+	var current *testPoint
+	output := []string{}
+
+	finishTest := func() {
+		if current == nil {
+			return
+		}
+		current.finish()
+		suite.TestCases = append(suite.TestCases, current.testCase)
+		current = nil
+	}
+	finishSubtest := func(description string) {
+		if subtest == nil {
+			return
+		}
+		if subtest.Name == "" {
+			subtest.Name = subtestName
+		}
+		if subtest.Name == "" {
+			subtest.Name = description
+		}
+		suite.TestSuites = append(suite.TestSuites, *subtest)
+		subtest = nil
+		subtestName = ""
+	}
+
+	for p.pos < len(p.lines) && !p.bailed {
+		raw := p.lines[p.pos]
+		if strings.TrimSpace(raw) == "" {
+			p.pos++
+			continue
+		}
+		lineIndent := len(raw) - len(strings.TrimLeft(raw, " "))
+		if lineIndent < indent {
+			break
+		}
+		line := raw[indent:]
+		trimmed := strings.TrimSpace(line)
+
+		if lineIndent >= indent+subtestIndent && isTAPLine(trimmed) {
+			finishTest()
+			finishSubtest("")
+			child := p.parseSuite("", lineIndent)
+			subtest = &child
+			continue
+		}
+		p.pos++
+
+		switch {
+		case strings.HasPrefix(trimmed, "TAP version") || strings.HasPrefix(trimmed, "pragma "):
+		case strings.HasPrefix(trimmed, "# Subtest"):
+			finishTest()
+			subtestName = strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(trimmed, "# Subtest"), ":"))
+			if suite.Name == "" && len(suite.TestCases) == 0 && indent > 0 {
+				// TAP 14 puts the name at the start of the indented stream
+				suite.Name = subtestName
+				subtestName = ""
+			}
+		case planRegex.MatchString(trimmed):
+			finishTest()
+			matches := planRegex.FindStringSubmatch(trimmed)
+			planned, _ = strconv.Atoi(matches[1])
+			if matches[2] != "" {
+				// e.g. "1..0 # SKIP no database"
+				output = append(output, matches[2])
+			}
+			p.foundTAP = true
+		case testRegex.MatchString(trimmed) && lineIndent == indent:
+			finishTest()
+			p.foundTAP = true
+			current = newTestPoint(testRegex.FindStringSubmatch(trimmed), len(suite.TestCases)+1)
+			finishSubtest(current.testCase.Name)
+			current.readYAML(p, indent)
+		case strings.HasPrefix(trimmed, "Bail out!"):
+			finishTest()
+			reason := strings.TrimSpace(strings.TrimPrefix(trimmed, "Bail out!"))
+			suite.TestCases = append(suite.TestCases, junit.TestCase{
+				Name:  "Bail out!",
+				Error: &junit.Error{Message: reason},
+			})
+			p.bailed = true
+		default:
+			text := strings.TrimPrefix(strings.TrimPrefix(trimmed, "#"), " ")
+			if current != nil {
+				current.output = append(current.output, text)
+			} else {
+				output = append(output, text)
+			}
+		}
+	}
+	finishTest()
+	finishSubtest("")
+
+	if planned >= 0 && planned != len(suite.TestCases) && !p.bailed {
+		suite.TestCases = append(suite.TestCases, junit.TestCase{
+			Name: "plan",
+			Error: &junit.Error{
+				Message: fmt.Sprintf("planned %d tests but ran %d", planned, len(suite.TestCases)),
+			},
+		})
+	}
+	if len(output) > 0 {
+		suite.SystemOut = &junit.SystemOut{Contents: strings.Join(output, "\n")}
+	}
+	return suite
+}
+
+func newTestPoint(matches []string, number int) *testPoint {
+	description, directive := splitDirective(matches[3])
+	if description == "" {
+		description = fmt.Sprintf("test %d", number)
+	}
+	point := &testPoint{
+		testCase: junit.TestCase{
+			Name: description,
+		},
+	}
+
+	notOK := matches[1] != ""
+	directiveMatches := directiveRegex.FindStringSubmatch(directive)
+	switch {
+	case directiveMatches != nil && strings.EqualFold(directiveMatches[1], "skip"):
+		point.testCase.Skipped = &junit.Skipped{Message: directiveMatches[2]}
+	case directiveMatches != nil && notOK:
+		// a failing TODO test is expected to fail
+		point.testCase.Skipped = &junit.Skipped{Message: strings.TrimSpace("TODO " + directiveMatches[2])}
+	case notOK:
+		point.failed = true
+		point.testCase.Failure = &junit.Failure{Message: description}
+	}
+	return point
+}
+
+// readYAML consumes a YAML diagnostic block directly following the test line.
+func (t *testPoint) readYAML(p *parser, indent int) {
+	if p.pos >= len(p.lines) || strings.TrimSpace(p.lines[p.pos]) != "---" {
+		return
+	}
+	blockIndent := len(p.lines[p.pos]) - len(strings.TrimLeft(p.lines[p.pos], " "))
+	if blockIndent <= indent {
+		return
+	}
+
+	block := []string{}
+	for p.pos++; p.pos < len(p.lines); p.pos++ {
+		line := p.lines[p.pos]
+		if strings.TrimSpace(line) == "..." {
+			p.pos++
+			break
+		}
+		if len(line) >= blockIndent && strings.TrimSpace(line[:blockIndent]) == "" {
+			line = line[blockIndent:]
+		}
+		block = append(block, line)
+	}
+	t.output = append(t.output, block...)
+
+	diagnostics := map[string]interface{}{}
+	if err := yaml.Unmarshal([]byte(strings.Join(block, "\n")), &diagnostics); err != nil {
+		return
+	}
+	if message, ok := diagnostics["message"].(string); ok && t.testCase.Failure != nil {
+		t.testCase.Failure.Message = strings.TrimSpace(message)
+	}
+	switch duration := diagnostics["duration_ms"].(type) {
+	case int:
+		t.testCase.Time = float64(duration) / 1000
+	case float64:
+		t.testCase.Time = duration / 1000
+	}
+}
+
+func (t *testPoint) finish() {
+	if len(t.output) == 0 {
+		return
+	}
+	contents := strings.Join(t.output, "\n")
+	if t.failed {
+		t.testCase.Failure.Contents = contents
+	} else {
+		t.testCase.SystemOut = &junit.SystemOut{Contents: contents}
+	}
+}
+
+// splitDirective splits a description at the first unescaped '#'.
+func splitDirective(text string) (string, string) {
+	description := strings.Builder{}
+	for i := 0; i < len(text); i++ {
+		switch {
+		case text[i] == '\\' && i+1 < len(text) && (text[i+1] == '#' || text[i+1] == '\\'):
+			i++
+			description.WriteByte(text[i])
+		case text[i] == '#':
+			return strings.TrimSpace(description.String()), strings.TrimSpace(text[i+1:])
+		default:
+			description.WriteByte(text[i])
+		}
+	}
+	return strings.TrimSpace(description.String()), ""
+}
+
+func isTAPLine(line string) bool {
+	return testRegex.MatchString(line) ||
+		planRegex.MatchString(line) ||
+		strings.HasPrefix(line, "# Subtest") ||
+		strings.HasPrefix(line, "TAP version") ||
+		strings.HasPrefix(line, "Bail out!")
+}
+
+// finalize prefixes the name of each subtest with the names of its
+// parents, e.g. "login/valid/password", and fills in the counts which
+// JUnit producers record on each suite.
+func finalize(suite *junit.TestSuite) {
+	suite.Tests, suite.Failures, suite.Errors, suite.Skipped, suite.Time = 0, 0, 0, 0, 0
+	for i := range suite.TestCases {
+		testCase := &suite.TestCases[i]
+		testCase.ClassName = suite.Name
+		suite.Tests++
+		switch testCase.Status() {
+		case junit.StatusFailed:
+			suite.Failures++
+		case junit.StatusError:
+			suite.Errors++
+		case junit.StatusSkipped:
+			suite.Skipped++
+		}
+		suite.Time += testCase.Time
+	}
+	for i := range suite.TestSuites {
+		nested := &suite.TestSuites[i]
+		nested.Name = fmt.Sprintf("%s/%s", suite.Name, nested.Name)
+		finalize(nested)
+		suite.Tests += nested.Tests
+		suite.Failures += nested.Failures
+		suite.Errors += nested.Errors
+		suite.Skipped += nested.Skipped
+		suite.Time += nested.Time
+	}
+}
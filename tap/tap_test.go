@@ -0,0 +1,138 @@
+package tap_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ljfranklin/test-runner-resource/junit"
+	"github.com/ljfranklin/test-runner-resource/tap"
+	"github.com/ljfranklin/test-runner-resource/test/helpers"
+)
+
+func fixturePath(fixture string) string {
+	return filepath.Join("..", "fixtures", "tap", fixture)
+}
+
+func statuses(suites junit.TestSuites) map[string]string {
+	result := map[string]string{}
+	suites.Walk(func(suite junit.TestSuite, testCase junit.TestCase) {
+		result[suite.Name+": "+testCase.Name] = testCase.Status()
+	})
+	return result
+}
+
+func TestParseBats(t *testing.T) {
+	suites, err := tap.ParseFile(fixturePath("bats.tap"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	helpers.AssertEquals(t, suites.Totals(), junit.Totals{Tests: 4, Failures: 1, Skipped: 1})
+	helpers.AssertEquals(t, len(suites.TestSuites), 1)
+
+	suite := suites.TestSuites[0]
+	helpers.AssertEquals(t, suite.Name, "bats")
+	helpers.AssertEquals(t, suite.TestCases[1].Failure, &junit.Failure{
+		Message:  "addition using dc",
+		Contents: "(in test file test/math.bats, line 12)\n  `[ \"$result\" -eq 4 ]' failed\nexpected 4 but got 5",
+	})
+	helpers.AssertEquals(t, suite.TestCases[2].Skipped, &junit.Skipped{Message: "dc is not installed"})
+}
+
+func TestParseTAP14(t *testing.T) {
+	suites, err := tap.ParseFile(fixturePath("tap14.tap"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	helpers.AssertEquals(t, statuses(suites), map[string]string{
+		"tap14: connects to the database":                      junit.StatusPassed,
+		"tap14: migrates the schema":                           junit.StatusFailed,
+		"tap14: handles # in descriptions":                     junit.StatusSkipped,
+		"tap14: supports unicode":                              junit.StatusSkipped,
+		"tap14: login":                                         junit.StatusFailed,
+		"tap14/login: accepts valid password":                  junit.StatusPassed,
+		"tap14/login: invalid password":                        junit.StatusFailed,
+		"tap14/login/invalid password: rejects empty password": junit.StatusFailed,
+	})
+
+	suite := suites.TestSuites[0]
+	helpers.AssertEquals(t, suite.Tests, 8)
+	helpers.AssertEquals(t, suite.TestSuites[0].Tests, 3)
+
+	migrate := suite.TestCases[1]
+	helpers.AssertEquals(t, migrate.Failure.Message, `relation "users" already exists`)
+	helpers.AssertEquals(t, migrate.Time, 0.25)
+	if !strings.Contains(migrate.Failure.Contents, "file: t/migrate.t") {
+		t.Fatalf("expected failure to include the YAML diagnostics, but it did not: %s", migrate.Failure.Contents)
+	}
+	helpers.AssertEquals(t, suite.TestCases[3].Skipped, &junit.Skipped{Message: "TODO not implemented"})
+}
+
+func TestParseRecordsBailOut(t *testing.T) {
+	suites, err := tap.ParseFile(fixturePath("bail-out.tap"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	helpers.AssertEquals(t, statuses(suites), map[string]string{
+		"bail-out: starts the server": junit.StatusPassed,
+		"bail-out: Bail out!":         junit.StatusError,
+	})
+	helpers.AssertEquals(t, suites.TestSuites[0].TestCases[1].Error, &junit.Error{Message: "database is unreachable"})
+}
+
+func TestParseRecordsPlanMismatch(t *testing.T) {
+	suites, err := tap.ParseFile(fixturePath("missing-tests.tap"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	helpers.AssertEquals(t, suites.Totals(), junit.Totals{Tests: 3, Errors: 1})
+	helpers.AssertEquals(t, suites.TestSuites[0].TestCases[2].Error, &junit.Error{Message: "planned 3 tests but ran 2"})
+}
+
+func TestParseIgnoresOutputStartingWithOk(t *testing.T) {
+	input := "1..2\nok 1 - connects\nok, done\nok: connected\nokay\nok 2 - migrates\n"
+	suites, err := tap.Parse(strings.NewReader(input), "integration")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	helpers.AssertEquals(t, suites.Totals(), junit.Totals{Tests: 2})
+	helpers.AssertEquals(t, statuses(suites), map[string]string{
+		"integration: connects": junit.StatusPassed,
+		"integration: migrates": junit.StatusPassed,
+	})
+}
+
+func TestParseSkippedPlan(t *testing.T) {
+	suites, err := tap.Parse(strings.NewReader("TAP version 13\n1..0 # SKIP no database\n"), "integration")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	helpers.AssertEquals(t, suites.Totals(), junit.Totals{})
+	helpers.AssertEquals(t, suites.TestSuites[0].SystemOut, &junit.SystemOut{Contents: "SKIP no database"})
+}
+
+func TestParseErrorOnMissingTAP(t *testing.T) {
+	_, err := tap.Parse(strings.NewReader("no tests here\n"), "integration")
+	if err == nil {
+		t.Fatal("expected err to occur but it did not")
+	}
+	if !strings.Contains(err.Error(), "found no TAP") {
+		t.Fatalf("expected err to contain 'found no TAP', but it did not: %s", err)
+	}
+}
+
+func TestParseFileErrorIncludesPath(t *testing.T) {
+	_, err := tap.ParseFile(fixturePath("../junit/success.xml"))
+	if err == nil {
+		t.Fatal("expected err to occur but it did not")
+	}
+	if !strings.Contains(err.Error(), "success.xml") {
+		t.Fatalf("expected err to contain 'success.xml', but it did not: %s", err)
+	}
+}
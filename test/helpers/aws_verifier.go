@@ -80,7 +80,7 @@ func (a AWSVerifier) ExpectS3ObjectToNotExist(t *testing.T, bucketName string, k
 		t.Fatalf("Invalid AWS error type: %s", err)
 	}
 	if reqErr.StatusCode() != 404 {
-		t.Fatalf("Expected req to return 404 but was %s", reqErr.StatusCode())
+		t.Fatalf("Expected req to return 404 but was %d", reqErr.StatusCode())
 	}
 }
 
@@ -0,0 +1,314 @@
+package viewer
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+
+	"github.com/ljfranklin/test-runner-resource/history"
+	"github.com/ljfranklin/test-runner-resource/junit"
+	"github.com/ljfranklin/test-runner-resource/models"
+)
+
+const HTMLFilename = "index.html"
+
+// HTMLDashboard writes a single-page dashboard covering every run to
+// ResultsDir. The page inlines its styles and scripts so it can be
+// archived as a build artifact.
+type HTMLDashboard struct {
+	ResultsDir string
+}
+
+type dashboard struct {
+	Runs     []dashboardRun
+	RunKeys  []string
+	Heatmap  []heatmapRow
+	Flaky    []history.FlakyStat
+	Slowest  []history.DurationStat
+	TopCount int
+	// whether any run in the window has failures
+	HasFailures bool
+}
+
+type dashboardRun struct {
+	Key      string
+	Time     string
+	Totals   junit.Totals
+	Passed   int
+	Failures []dashboardFailure
+}
+
+type dashboardFailure struct {
+	Test      string
+	Status    string
+	Message   string
+	Contents  string
+	SystemOut string
+	SystemErr string
+}
+
+type heatmapRow struct {
+	Test  string
+	Cells []string
+}
+
+func (h HTMLDashboard) WriteReport(summaries []models.Summary, runs []history.Run) error {
+	if err := requireRuns(runs); err != nil {
+		return err
+	}
+
+	top := defaultTop
+	for _, summary := range summaries {
+		if topCount(summary) > top {
+			top = topCount(summary)
+		}
+	}
+
+	contents := bytes.Buffer{}
+	if err := dashboardTemplate.Execute(&contents, buildDashboard(runs, top)); err != nil {
+		return fmt.Errorf("failed to render html report: %s", err)
+	}
+
+	outputPath := filepath.Join(h.ResultsDir, HTMLFilename)
+	if err := ioutil.WriteFile(outputPath, contents.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write html report: %s", err)
+	}
+	return nil
+}
+
+// buildDashboard expects runs newest first, but lays out the bars
+// and heatmap oldest first so time reads left to right.
+func buildDashboard(runs []history.Run, top int) dashboard {
+	d := dashboard{
+		TopCount: top,
+	}
+
+	statuses := map[string]map[string]string{}
+	failing := map[string]bool{}
+	for i := len(runs) - 1; i >= 0; i-- {
+		run := runs[i]
+		totals := run.Suites.Totals()
+		dRun := dashboardRun{
+			Key:    run.Key,
+			Time:   run.Timestamp.Format(runTimeFormat),
+			Totals: totals,
+			Passed: totals.Passed(),
+		}
+		run.Suites.Walk(func(suite junit.TestSuite, testCase junit.TestCase) {
+			id := history.NewTestID(suite, testCase).String()
+			status := testCase.Status()
+			if statuses[id] == nil {
+				statuses[id] = map[string]string{}
+			}
+			statuses[id][run.Key] = status
+			if !isFailure(testCase) {
+				return
+			}
+			failing[id] = true
+			d.HasFailures = true
+			dRun.Failures = append(dRun.Failures, newDashboardFailure(id, testCase))
+		})
+		d.Runs = append(d.Runs, dRun)
+		d.RunKeys = append(d.RunKeys, dRun.Time)
+	}
+
+	tests := []string{}
+	for id := range statuses {
+		tests = append(tests, id)
+	}
+	// tests which ever failed are listed first
+	sort.Slice(tests, func(i, j int) bool {
+		if failing[tests[i]] != failing[tests[j]] {
+			return failing[tests[i]]
+		}
+		return tests[i] < tests[j]
+	})
+	for _, id := range tests {
+		row := heatmapRow{Test: id}
+		for _, run := range d.Runs {
+			status, ok := statuses[id][run.Key]
+			if !ok {
+				status = "missing"
+			}
+			row.Cells = append(row.Cells, status)
+		}
+		d.Heatmap = append(d.Heatmap, row)
+	}
+
+	d.Flaky = history.Flaky(runs)
+	if len(d.Flaky) > top {
+		d.Flaky = d.Flaky[:top]
+	}
+	d.Slowest = history.TestDurations(runs)
+	if len(d.Slowest) > top {
+		d.Slowest = d.Slowest[:top]
+	}
+
+	return d
+}
+
+func newDashboardFailure(id string, testCase junit.TestCase) dashboardFailure {
+	failure := dashboardFailure{
+		Test:   id,
+		Status: testCase.Status(),
+	}
+	if testCase.Failure != nil {
+		failure.Message = testCase.Failure.Message
+		failure.Contents = testCase.Failure.Contents
+	}
+	if testCase.Error != nil {
+		failure.Message = testCase.Error.Message
+		failure.Contents = testCase.Error.Contents
+	}
+	if testCase.SystemOut != nil {
+		failure.SystemOut = testCase.SystemOut.Contents
+	}
+	if testCase.SystemErr != nil {
+		failure.SystemErr = testCase.SystemErr.Contents
+	}
+	return failure
+}
+
+func percent(part int, total int) string {
+	if total == 0 {
+		return "0"
+	}
+	return fmt.Sprintf("%.2f", float64(part)*100/float64(total))
+}
+
+var dashboardTemplate = template.Must(template.New("dashboard").Funcs(template.FuncMap{
+	"percent": percent,
+}).Parse(dashboardHTML))
+
+const dashboardHTML = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Test Summary</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #24292e; }
+h1, h2 { font-weight: 600; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { border: 1px solid #d1d5da; padding: 4px 8px; text-align: left; font-size: 13px; }
+th.sortable { cursor: pointer; background: #f6f8fa; }
+th.sortable:after { content: " \2195"; color: #959da5; }
+.bars td.label { white-space: nowrap; }
+.bar { display: flex; width: 400px; height: 14px; background: #eee; }
+.bar div { height: 100%; }
+.passed { background: #28a745; }
+.failed { background: #d73a49; }
+.error { background: #e36209; }
+.skipped { background: #959da5; }
+.missing { background: #fff; }
+.heatmap td.cell { width: 14px; padding: 0; }
+.heatmap th.run { writing-mode: vertical-rl; font-weight: normal; font-size: 11px; }
+details { margin: 4px 0; }
+summary { cursor: pointer; }
+pre { background: #f6f8fa; padding: 8px; overflow-x: auto; white-space: pre-wrap; }
+</style>
+</head>
+<body>
+<h1>Test Summary</h1>
+
+<h2>Runs</h2>
+<table class="bars">
+<tr><th>Run</th><th>Results</th><th>Tests</th><th>Passed</th><th>Failed</th><th>Errors</th><th>Skipped</th><th>Time</th></tr>
+{{- range .Runs}}
+<tr>
+<td class="label">{{.Time}}</td>
+<td><div class="bar" title="{{.Passed}} passed, {{.Totals.Failures}} failed, {{.Totals.Errors}} errors, {{.Totals.Skipped}} skipped">
+<div class="passed" style="width: {{percent .Passed .Totals.Tests}}%"></div>
+<div class="failed" style="width: {{percent .Totals.Failures .Totals.Tests}}%"></div>
+<div class="error" style="width: {{percent .Totals.Errors .Totals.Tests}}%"></div>
+<div class="skipped" style="width: {{percent .Totals.Skipped .Totals.Tests}}%"></div>
+</div></td>
+<td>{{.Totals.Tests}}</td><td>{{.Passed}}</td><td>{{.Totals.Failures}}</td><td>{{.Totals.Errors}}</td><td>{{.Totals.Skipped}}</td><td>{{printf "%.3f" .Totals.Time}}s</td>
+</tr>
+{{- end}}
+</table>
+
+<h2>Tests by run</h2>
+<table class="heatmap">
+<tr><th>Test</th>{{range .RunKeys}}<th class="run">{{.}}</th>{{end}}</tr>
+{{- range .Heatmap}}
+<tr><td>{{.Test}}</td>{{range .Cells}}<td class="cell {{.}}" title="{{.}}"></td>{{end}}</tr>
+{{- end}}
+</table>
+
+<h2>Failures</h2>
+{{- if .HasFailures}}
+{{- range .Runs}}
+{{- if .Failures}}
+<details>
+<summary>{{.Time}}: {{len .Failures}} failing</summary>
+{{- range .Failures}}
+<details>
+<summary>{{.Test}} ({{.Status}}){{if .Message}}: {{.Message}}{{end}}</summary>
+{{- if .Contents}}
+<pre>{{.Contents}}</pre>
+{{- end}}
+{{- if .SystemOut}}
+<p>system-out:</p>
+<pre>{{.SystemOut}}</pre>
+{{- end}}
+{{- if .SystemErr}}
+<p>system-err:</p>
+<pre>{{.SystemErr}}</pre>
+{{- end}}
+</details>
+{{- end}}
+</details>
+{{- end}}
+{{- end}}
+{{- else}}
+<p>No failures found</p>
+{{- end}}
+
+<h2>Top {{.TopCount}} flaky tests</h2>
+{{- if .Flaky}}
+<table class="sortable">
+<tr><th class="sortable">Test</th><th class="sortable">Score</th><th class="sortable">Flips</th><th class="sortable">Passed</th><th class="sortable">Failed</th></tr>
+{{- range .Flaky}}
+<tr><td>{{.Test}}</td><td data-sort="{{.Score}}">{{printf "%.2f" .Score}}</td><td>{{.Flips}}</td><td>{{.Passes}}</td><td>{{.Failures}}</td></tr>
+{{- end}}
+</table>
+{{- else}}
+<p>No flaky tests found</p>
+{{- end}}
+
+<h2>Top {{.TopCount}} slowest tests</h2>
+{{- if .Slowest}}
+<table class="sortable">
+<tr><th class="sortable">Test</th><th class="sortable">Latest</th><th class="sortable">Median</th><th class="sortable">P95</th><th class="sortable">Change</th></tr>
+{{- range .Slowest}}
+<tr><td>{{.Test}}</td><td data-sort="{{.Latest}}">{{printf "%.3f" .Latest}}s</td><td data-sort="{{.Median}}">{{printf "%.3f" .Median}}s</td><td data-sort="{{.P95}}">{{printf "%.3f" .P95}}s</td><td data-sort="{{.Change}}">{{printf "%+.0f" .Change}}%</td></tr>
+{{- end}}
+</table>
+{{- else}}
+<p>No durations found</p>
+{{- end}}
+
+<script>
+document.querySelectorAll("table.sortable").forEach(function(table) {
+  table.querySelectorAll("th.sortable").forEach(function(th, column) {
+    var ascending = false;
+    th.addEventListener("click", function() {
+      ascending = !ascending;
+      var rows = Array.prototype.slice.call(table.rows, 1);
+      rows.sort(function(a, b) {
+        var x = a.cells[column].getAttribute("data-sort") || a.cells[column].textContent;
+        var y = b.cells[column].getAttribute("data-sort") || b.cells[column].textContent;
+        var result = isNaN(x) || isNaN(y) ? x.localeCompare(y) : x - y;
+        return ascending ? result : -result;
+      });
+      rows.forEach(function(row) { row.parentNode.appendChild(row); });
+    });
+  });
+});
+</script>
+</body>
+</html>
+`
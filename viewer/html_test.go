@@ -0,0 +1,89 @@
+package viewer_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ljfranklin/test-runner-resource/models"
+	"github.com/ljfranklin/test-runner-resource/viewer"
+)
+
+func TestHTMLWriteReport(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "html-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	writeFixture(t, "success.xml", tmpDir, "test-results-2018-03-15T14:22:46Z.xml")
+	writeFixture(t, "failures.xml", tmpDir, "test-results-2018-03-14T14:22:46Z.xml")
+	writeFixture(t, "testsuite-root.xml", tmpDir, "test-results-2018-03-13T14:22:46Z.xml")
+
+	report := viewer.HTMLDashboard{
+		ResultsDir: tmpDir,
+	}
+
+	err = report.WriteReport([]models.Summary{
+		{
+			Type:  "pass-fail",
+			Limit: 10,
+		},
+	}, loadRuns(t, tmpDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	contents, err := ioutil.ReadFile(filepath.Join(tmpDir, "index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := string(contents)
+
+	for _, expected := range []string{
+		"<!DOCTYPE html>",
+		"<h2>Runs</h2>",
+		`<div class="passed" style="width: 100.00%"></div>`,
+		`<div class="failed" style="width: 100.00%"></div>`,
+		`<th class="run">2018-03-13T14:22:46Z</th><th class="run">2018-03-14T14:22:46Z</th><th class="run">2018-03-15T14:22:46Z</th>`,
+		`<tr><td>github.com/ljfranklin/test-runner-resource/storage: TestS3Get</td><td class="cell missing" title="missing"></td><td class="cell failed" title="failed"></td><td class="cell passed" title="passed"></td></tr>`,
+		"<summary>2018-03-14T14:22:46Z: 8 failing</summary>",
+		"<pre>s3_test.go:102: AWS_ACCESS_KEY must be set</pre>",
+		"<p>system-out:</p>",
+		"502 Bad Gateway",
+		"<h2>Top 10 flaky tests</h2>",
+		"<h2>Top 10 slowest tests</h2>",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Fatalf("expected output to contain '%s' but it did not: %s", expected, output)
+		}
+	}
+
+	for _, external := range []string{"<link", "src="} {
+		if strings.Contains(output, external) {
+			t.Fatalf("expected output to have no external assets but it contained '%s'", external)
+		}
+	}
+}
+
+func TestHTMLErrorOnNoRuns(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "html-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	report := viewer.HTMLDashboard{
+		ResultsDir: tmpDir,
+	}
+
+	err = report.WriteReport([]models.Summary{}, nil)
+	if err == nil {
+		t.Fatal("expected error on no runs but it succeeded")
+	}
+	if !strings.Contains(err.Error(), "found no test results") {
+		t.Fatalf("expected error to contain 'found no test results' but it did not: %s", err.Error())
+	}
+}
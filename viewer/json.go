@@ -0,0 +1,169 @@
+package viewer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+
+	"github.com/ljfranklin/test-runner-resource/history"
+	"github.com/ljfranklin/test-runner-resource/junit"
+	"github.com/ljfranklin/test-runner-resource/models"
+)
+
+const JSONFilename = "summary.json"
+
+// JSONReport writes a models.SummaryJSON document for consumption by
+// other tooling.
+type JSONReport struct {
+	ResultsDir string
+}
+
+func (j JSONReport) WriteReport(summaries []models.Summary, runs []history.Run) error {
+	if err := requireRuns(runs); err != nil {
+		return err
+	}
+
+	document := models.SummaryJSON{
+		Version:   models.SummaryJSONVersion,
+		Runs:      runTotals(runs),
+		Tests:     testHistories(runs),
+		Summaries: []models.SummaryResult{},
+	}
+	for _, summary := range summaries {
+		result, err := summaryResult(runs, summary)
+		if err != nil {
+			return err
+		}
+		document.Summaries = append(document.Summaries, result)
+	}
+
+	contents, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal json report: %s", err)
+	}
+
+	outputPath := filepath.Join(j.ResultsDir, JSONFilename)
+	if err := ioutil.WriteFile(outputPath, contents, 0644); err != nil {
+		return fmt.Errorf("failed to write json report: %s", err)
+	}
+	return nil
+}
+
+func runTotals(runs []history.Run) []models.RunTotals {
+	totals := []models.RunTotals{}
+	for _, run := range runs {
+		runTotals := run.Suites.Totals()
+		totals = append(totals, models.RunTotals{
+			Key:       run.Key,
+			Source:    run.Source,
+			Timestamp: run.Timestamp.Format(runTimeFormat),
+			Tests:     runTotals.Tests,
+			Passed:    runTotals.Passed(),
+			Failures:  runTotals.Failures,
+			Errors:    runTotals.Errors,
+			Skipped:   runTotals.Skipped,
+			Time:      runTotals.Time,
+			Metadata:  run.Suites.Metadata(),
+		})
+	}
+	return totals
+}
+
+func testHistories(runs []history.Run) []models.TestHistory {
+	historiesByTest := map[history.TestID]*models.TestHistory{}
+	for _, run := range runs {
+		run.Suites.Walk(func(suite junit.TestSuite, testCase junit.TestCase) {
+			id := history.NewTestID(suite, testCase)
+			testHistory, ok := historiesByTest[id]
+			if !ok {
+				testHistory = &models.TestHistory{
+					Test: testRef(id),
+				}
+				historiesByTest[id] = testHistory
+			}
+			testHistory.Results = append(testHistory.Results, models.TestResult{
+				Key:    run.Key,
+				Status: testCase.Status(),
+				Time:   testCase.Time,
+			})
+		})
+	}
+
+	histories := []models.TestHistory{}
+	for _, testHistory := range historiesByTest {
+		histories = append(histories, *testHistory)
+	}
+	sort.Slice(histories, func(i, j int) bool {
+		a, b := histories[i].Test, histories[j].Test
+		if a.Suite != b.Suite {
+			return a.Suite < b.Suite
+		}
+		if a.ClassName != b.ClassName {
+			return a.ClassName < b.ClassName
+		}
+		return a.Name < b.Name
+	})
+	return histories
+}
+
+func summaryResult(runs []history.Run, summary models.Summary) (models.SummaryResult, error) {
+	outputPattern, err := compileOutputMatches(summary)
+	if err != nil {
+		return models.SummaryResult{}, err
+	}
+
+	result := models.SummaryResult{
+		Summary:          summary,
+		RunKeys:          []string{},
+		Flaky:            []models.FlakyTest{},
+		FrequentFailures: []models.FrequentFailure{},
+	}
+	runs = summaryWindow(runs, summary)
+	for _, run := range runs {
+		result.RunKeys = append(result.RunKeys, run.Key)
+	}
+
+	flaky := history.Flaky(runs)
+	if len(flaky) > topCount(summary) {
+		flaky = flaky[:topCount(summary)]
+	}
+	for _, stat := range flaky {
+		result.Flaky = append(result.Flaky, models.FlakyTest{
+			Test:      testRef(stat.Test),
+			Runs:      stat.Runs,
+			Passes:    stat.Passes,
+			Failures:  stat.Failures,
+			Flips:     stat.Flips,
+			Score:     stat.Score,
+			LatestKey: stat.LatestKey,
+		})
+	}
+
+	failures := history.FrequentFailures(runs, outputPattern)
+	if len(failures) > topCount(summary) {
+		failures = failures[:topCount(summary)]
+	}
+	for _, stat := range failures {
+		result.FrequentFailures = append(result.FrequentFailures, models.FrequentFailure{
+			Test:        testRef(stat.Test),
+			Runs:        stat.Runs,
+			Failures:    stat.Failures,
+			FailureRate: stat.FailureRate,
+			FirstKey:    stat.FirstKey,
+			LatestKey:   stat.LatestKey,
+			Message:     stat.Message,
+		})
+	}
+
+	return result, nil
+}
+
+func testRef(id history.TestID) models.TestRef {
+	return models.TestRef{
+		Suite:     id.Suite,
+		ClassName: id.ClassName,
+		Name:      id.Name,
+	}
+}
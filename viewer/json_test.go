@@ -0,0 +1,153 @@
+package viewer_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ljfranklin/test-runner-resource/models"
+	"github.com/ljfranklin/test-runner-resource/test/helpers"
+	"github.com/ljfranklin/test-runner-resource/viewer"
+)
+
+func TestJSONWriteReport(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "json-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	writeFixture(t, "metadata.xml", tmpDir, "test-results-2018-03-16T14:22:46Z.xml")
+	writeFixture(t, "success.xml", tmpDir, "test-results-2018-03-15T14:22:46Z.xml")
+	writeFixture(t, "failures.xml", tmpDir, "test-results-2018-03-14T14:22:46Z.xml")
+
+	report := viewer.JSONReport{
+		ResultsDir: tmpDir,
+	}
+
+	summaries := []models.Summary{
+		{
+			Type:  "frequent-failures",
+			Limit: 2,
+			Top:   1,
+		},
+		{
+			Type: "flaky",
+			Filter: models.Filter{
+				Job: "integration",
+			},
+		},
+	}
+	err = report.WriteReport(summaries, loadRuns(t, tmpDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	contents, err := ioutil.ReadFile(filepath.Join(tmpDir, "summary.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var document models.SummaryJSON
+	if err = json.Unmarshal(contents, &document); err != nil {
+		t.Fatal(err)
+	}
+
+	helpers.AssertEquals(t, document.Version, models.SummaryJSONVersion)
+
+	helpers.AssertEquals(t, len(document.Runs), 3)
+	helpers.AssertEquals(t, document.Runs[0], models.RunTotals{
+		Key:       "test-results-2018-03-16T14:22:46Z.xml",
+		Timestamp: "2018-03-16T14:22:46Z",
+		Tests:     2,
+		Passed:    1,
+		Failures:  1,
+		Time:      document.Runs[0].Time,
+		Metadata: map[string]string{
+			"branch":         "main",
+			"build_job_name": "integration",
+			"build_name":     "42",
+			"iaas":           "azure",
+		},
+	})
+	helpers.AssertEquals(t, document.Runs[2].Failures, 8)
+
+	var s3Get *models.TestHistory
+	for i, testHistory := range document.Tests {
+		if testHistory.Test.Name == "TestS3Get" {
+			s3Get = &document.Tests[i]
+		}
+	}
+	if s3Get == nil {
+		t.Fatalf("expected tests to include TestS3Get but it did not: %+v", document.Tests)
+	}
+	helpers.AssertEquals(t, s3Get.Test, models.TestRef{
+		Suite:     "github.com/ljfranklin/test-runner-resource/storage",
+		ClassName: "storage",
+		Name:      "TestS3Get",
+	})
+	helpers.AssertEquals(t, len(s3Get.Results), 2)
+	helpers.AssertEquals(t, s3Get.Results[0].Key, "test-results-2018-03-15T14:22:46Z.xml")
+	helpers.AssertEquals(t, s3Get.Results[0].Status, "passed")
+	helpers.AssertEquals(t, s3Get.Results[1].Key, "test-results-2018-03-14T14:22:46Z.xml")
+	helpers.AssertEquals(t, s3Get.Results[1].Status, "failed")
+
+	helpers.AssertEquals(t, len(document.Summaries), 2)
+	frequent := document.Summaries[0]
+	helpers.AssertEquals(t, frequent.Summary, summaries[0])
+	helpers.AssertEquals(t, frequent.RunKeys, []string{
+		"test-results-2018-03-16T14:22:46Z.xml",
+		"test-results-2018-03-15T14:22:46Z.xml",
+	})
+	helpers.AssertEquals(t, frequent.FrequentFailures, []models.FrequentFailure{
+		{
+			Test: models.TestRef{
+				Suite:     frequent.FrequentFailures[0].Test.Suite,
+				ClassName: frequent.FrequentFailures[0].Test.ClassName,
+				Name:      "TestScale",
+			},
+			Runs:        1,
+			Failures:    1,
+			FailureRate: 1,
+			FirstKey:    "test-results-2018-03-16T14:22:46Z.xml",
+			LatestKey:   "test-results-2018-03-16T14:22:46Z.xml",
+			Message:     frequent.FrequentFailures[0].Message,
+		},
+	})
+
+	flaky := document.Summaries[1]
+	helpers.AssertEquals(t, flaky.Summary.Filter, models.Filter{Job: "integration"})
+	helpers.AssertEquals(t, flaky.RunKeys, []string{
+		"test-results-2018-03-16T14:22:46Z.xml",
+	})
+	helpers.AssertEquals(t, flaky.Flaky, []models.FlakyTest{})
+}
+
+func TestJSONErrorOnInvalidOutputRegex(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "json-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	writeFixture(t, "success.xml", tmpDir, "test-results-2018-03-15T14:22:46Z.xml")
+
+	report := viewer.JSONReport{
+		ResultsDir: tmpDir,
+	}
+
+	err = report.WriteReport([]models.Summary{
+		{
+			Type:          "frequent-failures",
+			OutputMatches: "(",
+		},
+	}, loadRuns(t, tmpDir))
+	if err == nil {
+		t.Fatal("expected error on invalid regex but it succeeded")
+	}
+	if !strings.Contains(err.Error(), "invalid output_matches regex") {
+		t.Fatalf("expected error to contain 'invalid output_matches regex' but it did not: %s", err.Error())
+	}
+}
@@ -3,44 +3,70 @@ package viewer
 import (
 	"fmt"
 	"io"
-	"os/exec"
-	"path/filepath"
+	"strings"
+	"text/tabwriter"
 
+	"github.com/ljfranklin/test-runner-resource/history"
 	"github.com/ljfranklin/test-runner-resource/models"
 )
 
 // go:generate counterfeiter . Junit
 
+// Junit prints a summary of runs, ordered from newest to oldest.
 type Junit interface {
-	PrintSummary(models.Summary) error
+	PrintSummary(models.Summary, []history.Run) error
 }
 
-type JunitCLI struct {
+type JunitNative struct {
 	OutputWriter io.Writer
-	ResultsDir   string
 }
 
-func (j JunitCLI) PrintSummary(summary models.Summary) error {
-	xmlFiles, err := filepath.Glob(filepath.Join(j.ResultsDir, "*.xml"))
+func (j JunitNative) PrintSummary(summary models.Summary, runs []history.Run) error {
+	report, err := buildReport(runs, summary)
 	if err != nil {
-		return fmt.Errorf("unable to glob for files: %s", err)
+		return err
 	}
-	if len(xmlFiles) == 0 {
-		return fmt.Errorf("found no .xml files in results dir '%s'", j.ResultsDir)
-	}
-	args := []string{
-		"-o", summary.Type,
-		"-l", fmt.Sprintf("%d", summary.Limit),
-	}
-	args = append(args, xmlFiles...)
 
-	cmd := exec.Command("junit-viewer", args...)
-	cmd.Stdout = j.OutputWriter
-	cmd.Stderr = j.OutputWriter
+	return writeText(j.OutputWriter, report)
+}
 
-	err = cmd.Run()
-	if err != nil {
-		return fmt.Errorf("failed to print summary: %s", err)
+func writeText(w io.Writer, report summaryReport) error {
+	fmt.Fprintf(w, "%s:\n", report.title)
+
+	for _, section := range report.sections {
+		if section.title != "" {
+			fmt.Fprintf(w, "\n%s:\n", section.title)
+		}
+		fmt.Fprintln(w)
+
+		if len(section.rows) == 0 && section.emptyText != "" {
+			fmt.Fprintln(w, section.emptyText)
+			continue
+		}
+
+		table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(table, "%s\t\n", strings.Join(section.headers, "\t"))
+		for _, row := range section.rows {
+			fmt.Fprintf(table, "%s\t\n", strings.Join(row, "\t"))
+		}
+		if err := table.Flush(); err != nil {
+			return err
+		}
+
+		if section.footer != "" {
+			fmt.Fprintf(w, "\n%s\n", section.footer)
+		}
+		if len(section.list) > 0 {
+			fmt.Fprintf(w, "\n%s:\n", section.listTitle)
+			for i, item := range section.list {
+				if section.ordered {
+					fmt.Fprintf(w, "  %d. %s\n", i+1, item)
+				} else {
+					fmt.Fprintf(w, "  - %s\n", item)
+				}
+			}
+		}
 	}
+
 	return nil
 }
@@ -8,10 +8,36 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/ljfranklin/test-runner-resource/history"
 	"github.com/ljfranklin/test-runner-resource/models"
 	"github.com/ljfranklin/test-runner-resource/viewer"
 )
 
+func writeFixture(t *testing.T, fixture string, dir string, key string) {
+	t.Helper()
+
+	contents, err := ioutil.ReadFile(filepath.Join("..", "fixtures", "junit", fixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.MkdirAll(filepath.Join(dir, filepath.Dir(key)), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(dir, key), contents, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func loadRuns(t *testing.T, dir string) []history.Run {
+	t.Helper()
+
+	runs, err := history.Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return runs
+}
+
 func TestJunitPrintSummary(t *testing.T) {
 	tmpDir, err := ioutil.TempDir("", "junit-test")
 	if err != nil {
@@ -19,129 +45,410 @@ func TestJunitPrintSummary(t *testing.T) {
 	}
 	defer os.RemoveAll(tmpDir)
 
-	fixtureContents, err := ioutil.ReadFile(filepath.Join("..", "fixtures", "junit", "success.xml"))
+	writeFixture(t, "success.xml", tmpDir, "test-results-2018-03-15T14:22:46Z.xml")
+	writeFixture(t, "failures.xml", tmpDir, "test-results-2018-03-14T14:22:46Z.xml")
+
+	output := bytes.Buffer{}
+	junit := viewer.JunitNative{
+		OutputWriter: &output,
+	}
+
+	err = junit.PrintSummary(models.Summary{
+		Type:  "pass-fail",
+		Limit: 10,
+	}, loadRuns(t, tmpDir))
 	if err != nil {
 		t.Fatal(err)
 	}
-	err = ioutil.WriteFile(filepath.Join(tmpDir, "success.xml"), fixtureContents, 0755)
+
+	for _, expected := range []string{"Summary", "2018-03-15", "2018-03-14", "1 of 2 runs passed"} {
+		if !strings.Contains(output.String(), expected) {
+			t.Fatalf("expected output to contain '%s' but it did not: %s", expected, output.String())
+		}
+	}
+}
+
+func TestJunitEnforceLimit(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "junit-test")
 	if err != nil {
 		t.Fatal(err)
 	}
+	defer os.RemoveAll(tmpDir)
+
+	writeFixture(t, "success.xml", tmpDir, "test-results-2018-03-15T14:22:46Z.xml")
+	writeFixture(t, "failures.xml", tmpDir, "test-results-2018-03-14T14:22:46Z.xml")
 
 	output := bytes.Buffer{}
-	junit := viewer.JunitCLI{
+	junit := viewer.JunitNative{
 		OutputWriter: &output,
-		ResultsDir:   tmpDir,
 	}
 
 	err = junit.PrintSummary(models.Summary{
 		Type:  "pass-fail",
-		Limit: 10,
-	})
+		Limit: 1,
+	}, loadRuns(t, tmpDir))
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	if !strings.Contains(output.String(), "Summary") {
-		t.Fatalf("expected output to contain 'Summary' but it did not: %s", output.String())
+	if !strings.Contains(output.String(), "2018-03-15") {
+		t.Fatalf("expected output to contain '2018-03-15' but it did not: %s", output.String())
+	}
+	if strings.Contains(output.String(), "2018-03-14") {
+		t.Fatalf("expected output to not contain '2018-03-14' but it did: %s", output.String())
 	}
 }
 
-func TestJunitEnforceLimit(t *testing.T) {
+func TestJunitFilterByMetadata(t *testing.T) {
 	tmpDir, err := ioutil.TempDir("", "junit-test")
 	if err != nil {
 		t.Fatal(err)
 	}
 	defer os.RemoveAll(tmpDir)
 
-	newerFixture, err := ioutil.ReadFile(filepath.Join("..", "fixtures", "junit", "success.xml"))
+	writeFixture(t, "success.xml", tmpDir, "test-results-2018-03-17T14:22:46Z.xml")
+	writeFixture(t, "metadata.xml", tmpDir, "test-results-2018-03-16T14:22:46Z.xml")
+	writeFixture(t, "failures.xml", tmpDir, "test-results-2018-03-15T14:22:46Z.xml")
+
+	output := bytes.Buffer{}
+	junit := viewer.JunitNative{
+		OutputWriter: &output,
+	}
+
+	err = junit.PrintSummary(models.Summary{
+		Type:  "pass-fail",
+		Limit: 10,
+		Filter: models.Filter{
+			Metadata: map[string]string{
+				"iaas": "azure",
+			},
+			Job: "integration",
+		},
+	}, loadRuns(t, tmpDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{"last 1 runs", "job=integration, iaas=azure", "2018-03-16"} {
+		if !strings.Contains(output.String(), expected) {
+			t.Fatalf("expected output to contain '%s' but it did not: %s", expected, output.String())
+		}
+	}
+	for _, unexpected := range []string{"2018-03-17", "2018-03-15"} {
+		if strings.Contains(output.String(), unexpected) {
+			t.Fatalf("expected output to not contain '%s' but it did: %s", unexpected, output.String())
+		}
+	}
+}
+
+func TestJunitBySource(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "junit-test")
 	if err != nil {
 		t.Fatal(err)
 	}
-	err = ioutil.WriteFile(filepath.Join(tmpDir, "test-results-2018-03-15T14:22:46Z.xml"), newerFixture, 0755)
+	defer os.RemoveAll(tmpDir)
+
+	writeFixture(t, "success.xml", tmpDir, "team-b/test-results-2018-03-16T14:22:46Z.xml")
+	writeFixture(t, "success.xml", tmpDir, "team-a/test-results-2018-03-15T14:22:46Z.xml")
+	writeFixture(t, "failures.xml", tmpDir, "team-a/test-results-2018-03-14T14:22:46Z.xml")
+
+	output := bytes.Buffer{}
+	junit := viewer.JunitNative{
+		OutputWriter: &output,
+	}
+
+	err = junit.PrintSummary(models.Summary{
+		Type:     "pass-fail",
+		Limit:    10,
+		BySource: true,
+	}, loadRuns(t, tmpDir))
 	if err != nil {
 		t.Fatal(err)
 	}
-	olderFixture, err := ioutil.ReadFile(filepath.Join("..", "fixtures", "junit", "failures.xml"))
+
+	for _, expected := range []string{
+		"by source across 2 sources",
+		"team-a: Summary of last 2 runs (pass-fail)",
+		"1 of 2 runs passed",
+		"team-b: Summary of last 1 runs (pass-fail)",
+		"1 of 1 runs passed",
+	} {
+		if !strings.Contains(output.String(), expected) {
+			t.Fatalf("expected output to contain '%s' but it did not: %s", expected, output.String())
+		}
+	}
+	if strings.Index(output.String(), "team-a:") > strings.Index(output.String(), "team-b:") {
+		t.Fatalf("expected sources to be sorted by label: %s", output.String())
+	}
+
+	output.Reset()
+	err = junit.PrintSummary(models.Summary{
+		Type:   "pass-fail",
+		Limit:  10,
+		Filter: models.Filter{Source: "team-b"},
+	}, loadRuns(t, tmpDir))
 	if err != nil {
 		t.Fatal(err)
 	}
-	err = ioutil.WriteFile(filepath.Join(tmpDir, "test-results-2018-03-14T14:22:46Z.xml"), olderFixture, 0755)
+	for _, expected := range []string{"last 1 runs", "source=team-b", "2018-03-16"} {
+		if !strings.Contains(output.String(), expected) {
+			t.Fatalf("expected output to contain '%s' but it did not: %s", expected, output.String())
+		}
+	}
+}
+
+func TestJunitFilterByOutput(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "junit-test")
 	if err != nil {
 		t.Fatal(err)
 	}
+	defer os.RemoveAll(tmpDir)
+
+	writeFixture(t, "metadata.xml", tmpDir, "test-results-2018-03-16T14:22:46Z.xml")
+	writeFixture(t, "failures.xml", tmpDir, "test-results-2018-03-15T14:22:46Z.xml")
 
 	output := bytes.Buffer{}
-	junit := viewer.JunitCLI{
+	junit := viewer.JunitNative{
 		OutputWriter: &output,
-		ResultsDir:   tmpDir,
 	}
 
 	err = junit.PrintSummary(models.Summary{
-		Type:  "pass-fail",
-		Limit: 1,
-	})
+		Type:          "pass-fail",
+		Limit:         10,
+		OutputMatches: "50[0-9] Bad Gateway",
+	}, loadRuns(t, tmpDir))
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	if !strings.Contains(output.String(), "2018-03-15") {
-		t.Fatalf("expected output to contain '2018-03-15' but it did not: %s", output.String())
+	for _, expected := range []string{"/50[0-9] Bad Gateway/", "1 of 9 failures matched", "TestScale"} {
+		if !strings.Contains(output.String(), expected) {
+			t.Fatalf("expected output to contain '%s' but it did not: %s", expected, output.String())
+		}
 	}
-	if strings.Contains(output.String(), "2018-03-14") {
-		t.Fatalf("expected output to not contain '2018-03-14' but it did: %s", output.String())
+	if strings.Contains(output.String(), "TestS3Get") {
+		t.Fatalf("expected output to not contain 'TestS3Get' but it did: %s", output.String())
 	}
 }
 
-func TestErrorOnInvalidPath(t *testing.T) {
+func TestErrorOnInvalidOutputRegex(t *testing.T) {
 	output := bytes.Buffer{}
-	junit := viewer.JunitCLI{
+	junit := viewer.JunitNative{
 		OutputWriter: &output,
-		ResultsDir:   "some-fake-dir",
 	}
 
 	err := junit.PrintSummary(models.Summary{
-		Type: "pass-fail",
-	})
+		Type:          "pass-fail",
+		OutputMatches: "[invalid",
+	}, nil)
 	if err == nil {
-		t.Fatal("expected error on invalid path but it succeeded")
+		t.Fatal("expected error on invalid regex but it succeeded")
+	}
+	if !strings.Contains(err.Error(), "output_matches") {
+		t.Fatalf("expected error to contain 'output_matches' but it did not: %s", err.Error())
+	}
+}
+
+func TestErrorOnUnsupportedOutputMatches(t *testing.T) {
+	output := bytes.Buffer{}
+	junit := viewer.JunitNative{
+		OutputWriter: &output,
 	}
 
-	if !strings.Contains(err.Error(), "some-fake-dir") {
-		t.Fatalf("expected output to contain 'some-fake-dir' but it did not: %s", err.Error())
+	for _, summaryType := range []string{"flaky", "durations"} {
+		err := junit.PrintSummary(models.Summary{
+			Type:          summaryType,
+			OutputMatches: "502",
+		}, nil)
+		if err == nil {
+			t.Fatalf("expected error for summary type '%s' but it succeeded", summaryType)
+		}
+		if !strings.Contains(err.Error(), "output_matches is not supported") {
+			t.Fatalf("expected error to contain 'output_matches is not supported' but it did not: %s", err.Error())
+		}
 	}
 }
 
-func TestErrorOnInvalidType(t *testing.T) {
+func TestJunitFrequentFailures(t *testing.T) {
 	tmpDir, err := ioutil.TempDir("", "junit-test")
 	if err != nil {
 		t.Fatal(err)
 	}
 	defer os.RemoveAll(tmpDir)
 
-	fixtureContents, err := ioutil.ReadFile(filepath.Join("..", "fixtures", "junit", "success.xml"))
+	writeFixture(t, "failures.xml", tmpDir, "test-results-2018-03-16T14:22:46Z.xml")
+	writeFixture(t, "success.xml", tmpDir, "test-results-2018-03-15T14:22:46Z.xml")
+	writeFixture(t, "failures.xml", tmpDir, "test-results-2018-03-14T14:22:46Z.xml")
+
+	output := bytes.Buffer{}
+	junit := viewer.JunitNative{
+		OutputWriter: &output,
+	}
+
+	err = junit.PrintSummary(models.Summary{
+		Type:  "frequent-failures",
+		Limit: 10,
+		Top:   2,
+	}, loadRuns(t, tmpDir))
 	if err != nil {
 		t.Fatal(err)
 	}
-	err = ioutil.WriteFile(filepath.Join(tmpDir, "success.xml"), fixtureContents, 0755)
+
+	for _, expected := range []string{
+		"Top 2 most frequent failures over last 3 runs",
+		"2/3",
+		"67%",
+		"test-results-2018-03-14T14:22:46Z.xml",
+		"test-results-2018-03-16T14:22:46Z.xml",
+		"S3_COMPATIBLE_ACCESS_KEY must be set",
+	} {
+		if !strings.Contains(output.String(), expected) {
+			t.Fatalf("expected output to contain '%s' but it did not: %s", expected, output.String())
+		}
+	}
+	if strings.Count(output.String(), "must be set") != 2 {
+		t.Fatalf("expected output to list 2 tests but it did not: %s", output.String())
+	}
+}
+
+func TestJunitFlaky(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "junit-test")
 	if err != nil {
 		t.Fatal(err)
 	}
+	defer os.RemoveAll(tmpDir)
+
+	writeFixture(t, "failures.xml", tmpDir, "test-results-2018-03-16T14:22:46Z.xml")
+	writeFixture(t, "success.xml", tmpDir, "test-results-2018-03-15T14:22:46Z.xml")
+	writeFixture(t, "failures.xml", tmpDir, "test-results-2018-03-14T14:22:46Z.xml")
 
 	output := bytes.Buffer{}
-	junit := viewer.JunitCLI{
+	junit := viewer.JunitNative{
+		OutputWriter: &output,
+	}
+
+	err = junit.PrintSummary(models.Summary{
+		Type: "flaky",
+	}, loadRuns(t, tmpDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{"flaky tests over last 3 runs", "TestS3Get", "1.00"} {
+		if !strings.Contains(output.String(), expected) {
+			t.Fatalf("expected output to contain '%s' but it did not: %s", expected, output.String())
+		}
+	}
+	if strings.Contains(output.String(), "TestS3Get/Get") {
+		t.Fatalf("expected output to not contain tests which never failed but it did: %s", output.String())
+	}
+}
+
+func TestJunitDurations(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "junit-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	writeFixture(t, "failures.xml", tmpDir, "test-results-2018-03-16T14:22:46Z.xml")
+	writeFixture(t, "success.xml", tmpDir, "test-results-2018-03-15T14:22:46Z.xml")
+
+	output := bytes.Buffer{}
+	junit := viewer.JunitNative{
+		OutputWriter: &output,
+	}
+
+	err = junit.PrintSummary(models.Summary{
+		Type:                 "durations",
+		Top:                  1,
+		RegressionThreshold:  25,
+		RegressionMinSeconds: 0.1,
+	}, loadRuns(t, tmpDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{
+		"Durations over last 2 runs",
+		"Top 1 slowest tests",
+		"TestS3CompatibleList",
+		"Top 1 slowest suites",
+		"more than 25% and 0.1s slower",
+	} {
+		if !strings.Contains(output.String(), expected) {
+			t.Fatalf("expected output to contain '%s' but it did not: %s", expected, output.String())
+		}
+	}
+	if strings.Contains(output.String(), "TestS3List ") {
+		t.Fatalf("expected output to be limited to top 1 but it was not: %s", output.String())
+	}
+}
+
+func TestJunitListsFailingTestsInLatestRun(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "junit-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	writeFixture(t, "failures.xml", tmpDir, "test-results-2018-03-14T14:22:46Z.xml")
+
+	output := bytes.Buffer{}
+	junit := viewer.JunitNative{
+		OutputWriter: &output,
+	}
+
+	err = junit.PrintSummary(models.Summary{
+		Type: "pass-fail",
+	}, loadRuns(t, tmpDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(output.String(), "TestS3CompatibleList") {
+		t.Fatalf("expected output to contain 'TestS3CompatibleList' but it did not: %s", output.String())
+	}
+}
+
+func TestErrorOnNoRuns(t *testing.T) {
+	output := bytes.Buffer{}
+	junit := viewer.JunitNative{
+		OutputWriter: &output,
+	}
+
+	err := junit.PrintSummary(models.Summary{
+		Type: "pass-fail",
+	}, []history.Run{})
+	if err == nil {
+		t.Fatal("expected error on no runs but it succeeded")
+	}
+	if !strings.Contains(err.Error(), "found no test results") {
+		t.Fatalf("expected error to contain 'found no test results' but it did not: %s", err.Error())
+	}
+}
+
+func TestErrorOnInvalidType(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "junit-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	writeFixture(t, "success.xml", tmpDir, "test-results-2018-03-15T14:22:46Z.xml")
+
+	output := bytes.Buffer{}
+	junit := viewer.JunitNative{
 		OutputWriter: &output,
-		ResultsDir:   tmpDir,
 	}
 
 	err = junit.PrintSummary(models.Summary{
 		Type: "some-invalid-type",
-	})
+	}, loadRuns(t, tmpDir))
 	if err == nil {
 		t.Fatal("expected error on invalid type but it succeeded")
 	}
-
-	if !strings.Contains(output.String(), "some-invalid-type") {
-		t.Fatalf("expected output to contain 'some-invalid-type' but it did not: %s", output.String())
+	if !strings.Contains(err.Error(), "some-invalid-type") {
+		t.Fatalf("expected error to contain 'some-invalid-type' but it did not: %s", err.Error())
 	}
 }
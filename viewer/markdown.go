@@ -0,0 +1,116 @@
+package viewer
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/ljfranklin/test-runner-resource/history"
+	"github.com/ljfranklin/test-runner-resource/models"
+)
+
+const MarkdownFilename = "summary.md"
+
+type MarkdownReport struct {
+	ResultsDir string
+}
+
+func (m MarkdownReport) WriteReport(summaries []models.Summary, runs []history.Run) error {
+	contents := bytes.Buffer{}
+	fmt.Fprintf(&contents, "# Test Summary\n")
+	for _, summary := range summaries {
+		report, err := buildReport(runs, summary)
+		if err != nil {
+			return err
+		}
+		writeMarkdown(&contents, report)
+	}
+
+	outputPath := filepath.Join(m.ResultsDir, MarkdownFilename)
+	if err := ioutil.WriteFile(outputPath, contents.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write markdown report: %s", err)
+	}
+	return nil
+}
+
+func writeMarkdown(w io.Writer, report summaryReport) {
+	fmt.Fprintf(w, "\n## %s\n", escapeMarkdown(report.title))
+
+	for _, section := range report.sections {
+		if section.title != "" {
+			fmt.Fprintf(w, "\n### %s\n", escapeMarkdown(section.title))
+		}
+		fmt.Fprintln(w)
+
+		if len(section.rows) == 0 && section.emptyText != "" {
+			fmt.Fprintf(w, "_%s_\n", section.emptyText)
+			continue
+		}
+
+		writeMarkdownRow(w, section.headers)
+		separators := []string{}
+		for range section.headers {
+			separators = append(separators, "---")
+		}
+		writeMarkdownRow(w, separators)
+		for _, row := range section.rows {
+			writeMarkdownRow(w, row)
+		}
+
+		if section.footer != "" {
+			fmt.Fprintf(w, "\n%s\n", escapeMarkdown(section.footer))
+		}
+		if len(section.list) > 0 {
+			fmt.Fprintf(w, "\n**%s:**\n\n", escapeMarkdown(section.listTitle))
+			for i, item := range section.list {
+				if section.ordered {
+					fmt.Fprintf(w, "%d. %s\n", i+1, markdownCode(item))
+				} else {
+					fmt.Fprintf(w, "- %s\n", markdownCode(item))
+				}
+			}
+		}
+	}
+}
+
+func writeMarkdownRow(w io.Writer, cells []string) {
+	escaped := []string{}
+	for _, cell := range cells {
+		escaped = append(escaped, strings.Replace(escapeMarkdown(cell), "|", `\|`, -1))
+	}
+	fmt.Fprintf(w, "| %s |\n", strings.Join(escaped, " | "))
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"*", `\*`,
+	"_", `\_`,
+	"`", "\\`",
+	"<", "&lt;",
+	">", "&gt;",
+	"\n", " ",
+)
+
+func escapeMarkdown(text string) string {
+	return markdownEscaper.Replace(text)
+}
+
+// markdownCode wraps free-form output, e.g. failure messages, in an
+// inline code span so it is rendered verbatim.
+func markdownCode(text string) string {
+	text = strings.Replace(text, "\n", " ", -1)
+	fence := "`"
+	for strings.Contains(text, fence) {
+		fence += "`"
+	}
+	if text == "" {
+		return text
+	}
+	if strings.HasPrefix(text, "`") || strings.HasSuffix(text, "`") {
+		text = " " + text + " "
+	}
+	return fence + text + fence
+}
@@ -0,0 +1,91 @@
+package viewer_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ljfranklin/test-runner-resource/models"
+	"github.com/ljfranklin/test-runner-resource/viewer"
+)
+
+func TestMarkdownWriteReport(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "markdown-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	writeFixture(t, "success.xml", tmpDir, "test-results-2018-03-15T14:22:46Z.xml")
+	writeFixture(t, "failures.xml", tmpDir, "test-results-2018-03-14T14:22:46Z.xml")
+
+	report := viewer.MarkdownReport{
+		ResultsDir: tmpDir,
+	}
+
+	err = report.WriteReport([]models.Summary{
+		{
+			Type:  "pass-fail",
+			Limit: 10,
+		},
+		{
+			Type: "frequent-failures",
+			Top:  1,
+		},
+		{
+			Type: "flaky",
+		},
+	}, loadRuns(t, tmpDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	contents, err := ioutil.ReadFile(filepath.Join(tmpDir, "summary.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := string(contents)
+
+	for _, expected := range []string{
+		"# Test Summary\n",
+		"\n## Summary of last 2 runs (pass-fail)\n",
+		"| Run | Tests | Passed | Failed | Errors | Skipped | Time |\n| --- | --- | --- | --- | --- | --- | --- |\n",
+		"| 2018-03-15T14:22:46Z | 30 | 30 | 0 | 0 | 0 | 9.837s |\n",
+		"1 of 2 runs passed",
+		"\n## Top 1 most frequent failures over last 2 runs (frequent-failures)\n",
+		"1. `s3_test.go:186: S3_COMPATIBLE_ACCESS_KEY must be set`",
+		"\n## Top 10 flaky tests over last 2 runs (flaky)\n",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Fatalf("expected output to contain '%s' but it did not: %s", expected, output)
+		}
+	}
+}
+
+func TestMarkdownErrorOnInvalidType(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "markdown-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	writeFixture(t, "success.xml", tmpDir, "test-results-2018-03-15T14:22:46Z.xml")
+
+	report := viewer.MarkdownReport{
+		ResultsDir: tmpDir,
+	}
+
+	err = report.WriteReport([]models.Summary{
+		{
+			Type: "some-invalid-type",
+		},
+	}, loadRuns(t, tmpDir))
+	if err == nil {
+		t.Fatal("expected error on invalid type but it succeeded")
+	}
+	if !strings.Contains(err.Error(), "some-invalid-type") {
+		t.Fatalf("expected error to contain 'some-invalid-type' but it did not: %s", err.Error())
+	}
+}
@@ -0,0 +1,14 @@
+package viewer
+
+import (
+	"github.com/ljfranklin/test-runner-resource/history"
+	"github.com/ljfranklin/test-runner-resource/models"
+)
+
+// go:generate counterfeiter . Reporter
+
+// Reporter writes the requested summaries of runs, ordered from newest
+// to oldest, to a file in the results dir.
+type Reporter interface {
+	WriteReport([]models.Summary, []history.Run) error
+}
@@ -0,0 +1,382 @@
+package viewer
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ljfranklin/test-runner-resource/history"
+	"github.com/ljfranklin/test-runner-resource/junit"
+	"github.com/ljfranklin/test-runner-resource/models"
+)
+
+const (
+	defaultTop                 = 10
+	defaultRegressionThreshold = 50
+	defaultRegressionMinSecs   = 0.5
+	runTimeFormat              = "2006-01-02T15:04:05Z07:00"
+)
+
+// summaryReport is a format-agnostic rendering of a single summary
+// which is then written as plain text, markdown, etc.
+type summaryReport struct {
+	title    string
+	sections []reportSection
+}
+
+type reportSection struct {
+	title   string
+	headers []string
+	rows    [][]string
+	// shown in place of the table when there are no rows
+	emptyText string
+	footer    string
+	listTitle string
+	list      []string
+	ordered   bool
+}
+
+func buildReport(runs []history.Run, summary models.Summary) (summaryReport, error) {
+	outputPattern, err := compileOutputMatches(summary)
+	if err != nil {
+		return summaryReport{}, err
+	}
+
+	if err = requireRuns(runs); err != nil {
+		return summaryReport{}, err
+	}
+	if summary.BySource {
+		return bySourceReport(runs, summary, outputPattern)
+	}
+	return runsReport(runs, summary, outputPattern)
+}
+
+// bySourceReport builds a separate report for the runs of each
+// storage label and combines them as sections of a single report.
+func bySourceReport(runs []history.Run, summary models.Summary, outputPattern *regexp.Regexp) (summaryReport, error) {
+	sources := []string{}
+	runsBySource := map[string][]history.Run{}
+	for _, run := range runs {
+		if _, ok := runsBySource[run.Source]; !ok {
+			sources = append(sources, run.Source)
+		}
+		runsBySource[run.Source] = append(runsBySource[run.Source], run)
+	}
+	sort.Strings(sources)
+
+	report := summaryReport{
+		title: fmt.Sprintf("Summary (%s) by source across %d sources", summary.Type, len(sources)),
+	}
+	for _, source := range sources {
+		sourceReport, err := runsReport(runsBySource[source], summary, outputPattern)
+		if err != nil {
+			return summaryReport{}, err
+		}
+		if source == "" {
+			source = "(none)"
+		}
+		prefix := fmt.Sprintf("%s: %s", source, sourceReport.title)
+		if len(sourceReport.sections) == 0 {
+			report.sections = append(report.sections, reportSection{
+				title:     prefix,
+				emptyText: "No runs found",
+			})
+		}
+		for _, section := range sourceReport.sections {
+			if section.title == "" {
+				section.title = prefix
+			} else {
+				section.title = fmt.Sprintf("%s, %s", prefix, section.title)
+			}
+			report.sections = append(report.sections, section)
+		}
+	}
+
+	return report, nil
+}
+
+func runsReport(runs []history.Run, summary models.Summary, outputPattern *regexp.Regexp) (summaryReport, error) {
+	runs = summaryWindow(runs, summary)
+	if len(runs) == 0 {
+		return summaryReport{
+			title: fmt.Sprintf("Summary (%s): no runs match filter %s", summary.Type, describeFilter(summary.Filter)),
+		}, nil
+	}
+
+	switch summary.Type {
+	case "pass-fail":
+		if outputPattern != nil {
+			return outputMatchesReport(runs, outputPattern, summary.Filter), nil
+		}
+		return passFailReport(runs, summary.Filter), nil
+	case "frequent-failures":
+		return frequentFailuresReport(runs, topCount(summary), outputPattern, summary.Filter), nil
+	case "flaky":
+		return flakyReport(runs, topCount(summary), summary.Filter), nil
+	case "durations":
+		return durationsReport(runs, topCount(summary), regressionThreshold(summary), regressionMinSeconds(summary), summary.Filter), nil
+	default:
+		return summaryReport{}, fmt.Errorf("unrecognized summary type '%s'; set type to one of the following: 'pass-fail', 'frequent-failures', 'flaky', 'durations'", summary.Type)
+	}
+}
+
+func passFailReport(runs []history.Run, filter models.Filter) summaryReport {
+	section := reportSection{
+		headers:   []string{"Run", "Tests", "Passed", "Failed", "Errors", "Skipped", "Time"},
+		listTitle: "Failing tests in latest run",
+	}
+
+	passedRuns := 0
+	for _, run := range runs {
+		totals := run.Suites.Totals()
+		if totals.Failures+totals.Errors == 0 {
+			passedRuns++
+		}
+		section.rows = append(section.rows, []string{
+			run.Timestamp.Format(runTimeFormat),
+			fmt.Sprintf("%d", totals.Tests),
+			fmt.Sprintf("%d", totals.Passed()),
+			fmt.Sprintf("%d", totals.Failures),
+			fmt.Sprintf("%d", totals.Errors),
+			fmt.Sprintf("%d", totals.Skipped),
+			fmt.Sprintf("%.3fs", totals.Time),
+		})
+	}
+	section.footer = fmt.Sprintf("%d of %d runs passed", passedRuns, len(runs))
+
+	runs[0].Suites.Walk(func(suite junit.TestSuite, testCase junit.TestCase) {
+		if isFailure(testCase) {
+			section.list = append(section.list, history.NewTestID(suite, testCase).String())
+		}
+	})
+
+	return summaryReport{
+		title:    fmt.Sprintf("Summary of last %d runs (pass-fail)%s", len(runs), filterSuffix(filter)),
+		sections: []reportSection{section},
+	}
+}
+
+func outputMatchesReport(runs []history.Run, pattern *regexp.Regexp, filter models.Filter) summaryReport {
+	section := reportSection{
+		headers:   []string{"Run", "Failed", "Matching"},
+		listTitle: "Matching failures",
+	}
+
+	totalFailures := 0
+	totalMatches := 0
+	for _, run := range runs {
+		failures := 0
+		matches := 0
+		run.Suites.Walk(func(suite junit.TestSuite, testCase junit.TestCase) {
+			if !isFailure(testCase) {
+				return
+			}
+			failures++
+			if history.MatchesOutput(suite, testCase, pattern) {
+				matches++
+				section.list = append(section.list, fmt.Sprintf("%s %s", run.Timestamp.Format(runTimeFormat), history.NewTestID(suite, testCase)))
+			}
+		})
+		totalFailures += failures
+		totalMatches += matches
+		section.rows = append(section.rows, []string{
+			run.Timestamp.Format(runTimeFormat),
+			fmt.Sprintf("%d", failures),
+			fmt.Sprintf("%d", matches),
+		})
+	}
+	section.footer = fmt.Sprintf("%d of %d failures matched", totalMatches, totalFailures)
+
+	return summaryReport{
+		title:    fmt.Sprintf("Summary of last %d runs (pass-fail)%s, failures with output matching /%s/", len(runs), filterSuffix(filter), pattern),
+		sections: []reportSection{section},
+	}
+}
+
+func frequentFailuresReport(runs []history.Run, top int, pattern *regexp.Regexp, filter models.Filter) summaryReport {
+	suffix := filterSuffix(filter)
+	if pattern != nil {
+		suffix += fmt.Sprintf(", failures with output matching /%s/", pattern)
+	}
+	section := reportSection{
+		headers:   []string{"#", "Test", "Failures", "Rate", "First Failure", "Latest Failure"},
+		emptyText: "No failures found",
+		listTitle: "Latest failure messages",
+		ordered:   true,
+	}
+
+	stats := history.FrequentFailures(runs, pattern)
+	if len(stats) > top {
+		stats = stats[:top]
+	}
+	for i, stat := range stats {
+		section.rows = append(section.rows, []string{
+			fmt.Sprintf("%d", i+1),
+			stat.Test.String(),
+			fmt.Sprintf("%d/%d", stat.Failures, stat.Runs),
+			fmt.Sprintf("%.0f%%", stat.FailureRate*100),
+			stat.FirstKey,
+			stat.LatestKey,
+		})
+		section.list = append(section.list, stat.Message)
+	}
+
+	return summaryReport{
+		title:    fmt.Sprintf("Top %d most frequent failures over last %d runs (frequent-failures)%s", top, len(runs), suffix),
+		sections: []reportSection{section},
+	}
+}
+
+func flakyReport(runs []history.Run, top int, filter models.Filter) summaryReport {
+	section := reportSection{
+		headers:   []string{"#", "Test", "Score", "Flips", "Passed", "Failed", "Latest Run"},
+		emptyText: "No flaky tests found",
+	}
+
+	stats := history.Flaky(runs)
+	if len(stats) > top {
+		stats = stats[:top]
+	}
+	for i, stat := range stats {
+		section.rows = append(section.rows, []string{
+			fmt.Sprintf("%d", i+1),
+			stat.Test.String(),
+			fmt.Sprintf("%.2f", stat.Score),
+			fmt.Sprintf("%d", stat.Flips),
+			fmt.Sprintf("%d", stat.Passes),
+			fmt.Sprintf("%d", stat.Failures),
+			stat.LatestKey,
+		})
+	}
+
+	return summaryReport{
+		title:    fmt.Sprintf("Top %d flaky tests over last %d runs (flaky)%s", top, len(runs), filterSuffix(filter)),
+		sections: []reportSection{section},
+	}
+}
+
+func durationsReport(runs []history.Run, top int, threshold float64, minSeconds float64, filter models.Filter) summaryReport {
+	testStats := history.TestDurations(runs)
+	suiteStats := history.SuiteDurations(runs)
+	regressions := history.Regressions(testStats, threshold, minSeconds)
+
+	report := summaryReport{
+		title: fmt.Sprintf("Durations over last %d runs (durations)%s", len(runs), filterSuffix(filter)),
+	}
+	for _, group := range []struct {
+		title string
+		stats []history.DurationStat
+	}{
+		{fmt.Sprintf("Top %d slowest tests", top), testStats},
+		{fmt.Sprintf("Top %d slowest suites", top), suiteStats},
+		{fmt.Sprintf("Tests more than %.0f%% and %gs slower than their median", threshold, minSeconds), regressions},
+	} {
+		section := reportSection{
+			title:     group.title,
+			headers:   []string{"#", "Name", "Latest", "Median", "P95", "Change"},
+			emptyText: "None found",
+		}
+		stats := group.stats
+		if len(stats) > top {
+			stats = stats[:top]
+		}
+		for i, stat := range stats {
+			section.rows = append(section.rows, []string{
+				fmt.Sprintf("%d", i+1),
+				stat.Test.String(),
+				fmt.Sprintf("%.3fs", stat.Latest),
+				fmt.Sprintf("%.3fs", stat.Median),
+				fmt.Sprintf("%.3fs", stat.P95),
+				fmt.Sprintf("%+.0f%%", stat.Change),
+			})
+		}
+		report.sections = append(report.sections, section)
+	}
+
+	return report
+}
+
+func compileOutputMatches(summary models.Summary) (*regexp.Regexp, error) {
+	if summary.OutputMatches == "" {
+		return nil, nil
+	}
+	if summary.Type != "pass-fail" && summary.Type != "frequent-failures" {
+		return nil, fmt.Errorf("output_matches is not supported for summary type '%s'; use it with 'pass-fail' or 'frequent-failures'", summary.Type)
+	}
+	pattern, err := regexp.Compile(summary.OutputMatches)
+	if err != nil {
+		return nil, fmt.Errorf("invalid output_matches regex '%s': %s", summary.OutputMatches, err)
+	}
+	return pattern, nil
+}
+
+func requireRuns(runs []history.Run) error {
+	if len(runs) == 0 {
+		return errors.New("found no test results")
+	}
+	return nil
+}
+
+// summaryWindow returns the runs a summary applies to after
+// filtering and enforcing its limit.
+func summaryWindow(runs []history.Run, summary models.Summary) []history.Run {
+	runs = history.Filter(runs, summary.Filter)
+	if summary.Limit > 0 && len(runs) > summary.Limit {
+		runs = runs[:summary.Limit]
+	}
+	return runs
+}
+
+func isFailure(testCase junit.TestCase) bool {
+	status := testCase.Status()
+	return status == junit.StatusFailed || status == junit.StatusError
+}
+
+func topCount(summary models.Summary) int {
+	if summary.Top > 0 {
+		return summary.Top
+	}
+	return defaultTop
+}
+
+func regressionThreshold(summary models.Summary) float64 {
+	if summary.RegressionThreshold > 0 {
+		return summary.RegressionThreshold
+	}
+	return defaultRegressionThreshold
+}
+
+func regressionMinSeconds(summary models.Summary) float64 {
+	if summary.RegressionMinSeconds > 0 {
+		return summary.RegressionMinSeconds
+	}
+	return defaultRegressionMinSecs
+}
+
+func filterSuffix(filter models.Filter) string {
+	if !history.IsFiltered(filter) {
+		return ""
+	}
+	return fmt.Sprintf(" filtered by %s", describeFilter(filter))
+}
+
+func describeFilter(filter models.Filter) string {
+	conditions := []string{}
+	if filter.Source != "" {
+		conditions = append(conditions, fmt.Sprintf("source=%s", filter.Source))
+	}
+	if filter.Job != "" {
+		conditions = append(conditions, fmt.Sprintf("job=%s", filter.Job))
+	}
+	names := []string{}
+	for name := range filter.Metadata {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		conditions = append(conditions, fmt.Sprintf("%s=%s", name, filter.Metadata[name]))
+	}
+	return strings.Join(conditions, ", ")
+}
@@ -0,0 +1,165 @@
+package viewer
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/ljfranklin/test-runner-resource/history"
+	"github.com/ljfranklin/test-runner-resource/models"
+)
+
+const (
+	PassRateChartFilename = "pass-rate.svg"
+	DurationChartFilename = "duration.svg"
+	FailuresChartFilename = "failures.svg"
+
+	chartWidth   = 640
+	chartHeight  = 320
+	chartLeft    = 60
+	chartRight   = 20
+	chartTop     = 40
+	chartBottom  = 50
+	chartYTicks  = 4
+	chartPadding = 0.1
+)
+
+// SVGCharts writes trend charts covering every run to ResultsDir.
+type SVGCharts struct {
+	ResultsDir string
+}
+
+type chart struct {
+	title  string
+	labels []string
+	values []float64
+	// upper bound of the y axis, derived from values when 0
+	max float64
+	// y axis tick format, e.g. "%.0f%%"
+	format string
+	bars   bool
+}
+
+func (s SVGCharts) WriteReport(summaries []models.Summary, runs []history.Run) error {
+	if err := requireRuns(runs); err != nil {
+		return err
+	}
+
+	passRate := chart{
+		title:  "Pass rate",
+		max:    100,
+		format: "%.0f%%",
+	}
+	duration := chart{
+		title:  "Total duration",
+		format: "%.1fs",
+	}
+	failures := chart{
+		title:  "Failures per run",
+		format: "%.0f",
+		bars:   true,
+	}
+	for i := len(runs) - 1; i >= 0; i-- {
+		totals := runs[i].Suites.Totals()
+		label := runs[i].Timestamp.Format(runTimeFormat)
+
+		// matches the pass_rate metadata, runs where nothing executed chart as 0
+		rate, _ := history.PassRate(runs[i : i+1])
+		passRate.labels = append(passRate.labels, label)
+		passRate.values = append(passRate.values, rate)
+		duration.labels = append(duration.labels, label)
+		duration.values = append(duration.values, totals.Time)
+		failures.labels = append(failures.labels, label)
+		failures.values = append(failures.values, float64(totals.Failures+totals.Errors))
+	}
+
+	for filename, c := range map[string]chart{
+		PassRateChartFilename: passRate,
+		DurationChartFilename: duration,
+		FailuresChartFilename: failures,
+	} {
+		contents := bytes.Buffer{}
+		writeSVG(&contents, c)
+		if err := ioutil.WriteFile(filepath.Join(s.ResultsDir, filename), contents.Bytes(), 0644); err != nil {
+			return fmt.Errorf("failed to write chart '%s': %s", filename, err)
+		}
+	}
+	return nil
+}
+
+func writeSVG(w io.Writer, c chart) {
+	plotWidth := float64(chartWidth - chartLeft - chartRight)
+	plotHeight := float64(chartHeight - chartTop - chartBottom)
+
+	max := c.max
+	if max == 0 {
+		for _, value := range c.values {
+			if value > max {
+				max = value
+			}
+		}
+		max *= 1 + chartPadding
+	}
+	if max == 0 {
+		max = 1
+	}
+
+	// each run gets an equal slot with the point or bar centered in it
+	slot := plotWidth / float64(len(c.values))
+	x := func(i int) float64 {
+		return chartLeft + slot*(float64(i)+0.5)
+	}
+	y := func(value float64) float64 {
+		return chartTop + plotHeight - value/max*plotHeight
+	}
+
+	fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="11">`+"\n",
+		chartWidth, chartHeight, chartWidth, chartHeight)
+	fmt.Fprintf(w, `<rect width="%d" height="%d" fill="#fff"/>`+"\n", chartWidth, chartHeight)
+	fmt.Fprintf(w, `<text x="%d" y="%d" font-size="14" font-weight="bold">%s</text>`+"\n", chartLeft, chartTop/2+5, escapeXML(c.title))
+
+	for i := 0; i <= chartYTicks; i++ {
+		value := max * float64(i) / chartYTicks
+		fmt.Fprintf(w, `<line x1="%d" y1="%.1f" x2="%d" y2="%.1f" stroke="#e1e4e8"/>`+"\n",
+			chartLeft, y(value), chartWidth-chartRight, y(value))
+		fmt.Fprintf(w, `<text x="%d" y="%.1f" text-anchor="end">%s</text>`+"\n",
+			chartLeft-6, y(value)+4, escapeXML(fmt.Sprintf(c.format, value)))
+	}
+
+	if len(c.labels) > 0 {
+		fmt.Fprintf(w, `<text x="%d" y="%d">%s</text>`+"\n", chartLeft, chartHeight-chartBottom/2, escapeXML(c.labels[0]))
+		if len(c.labels) > 1 {
+			fmt.Fprintf(w, `<text x="%d" y="%d" text-anchor="end">%s</text>`+"\n",
+				chartWidth-chartRight, chartHeight-chartBottom/2, escapeXML(c.labels[len(c.labels)-1]))
+		}
+	}
+
+	if c.bars {
+		barWidth := slot * (1 - 2*chartPadding)
+		for i, value := range c.values {
+			fmt.Fprintf(w, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="#d73a49"><title>%s: %s</title></rect>`+"\n",
+				x(i)-barWidth/2, y(value), barWidth, y(0)-y(value), escapeXML(c.labels[i]), escapeXML(fmt.Sprintf(c.format, value)))
+		}
+	} else {
+		points := bytes.Buffer{}
+		for i, value := range c.values {
+			fmt.Fprintf(&points, "%.1f,%.1f ", x(i), y(value))
+		}
+		fmt.Fprintf(w, `<polyline points="%s" fill="none" stroke="#0366d6" stroke-width="2"/>`+"\n", bytes.TrimSpace(points.Bytes()))
+		for i, value := range c.values {
+			fmt.Fprintf(w, `<circle cx="%.1f" cy="%.1f" r="3" fill="#0366d6"><title>%s: %s</title></circle>`+"\n",
+				x(i), y(value), escapeXML(c.labels[i]), escapeXML(fmt.Sprintf(c.format, value)))
+		}
+	}
+
+	fmt.Fprintln(w, "</svg>")
+}
+
+func escapeXML(text string) string {
+	escaped := bytes.Buffer{}
+	xml.EscapeText(&escaped, []byte(text))
+	return escaped.String()
+}
@@ -0,0 +1,92 @@
+package viewer_test
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ljfranklin/test-runner-resource/models"
+	"github.com/ljfranklin/test-runner-resource/test/helpers"
+	"github.com/ljfranklin/test-runner-resource/viewer"
+)
+
+func TestSVGWriteReport(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "svg-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	writeFixture(t, "success.xml", tmpDir, "test-results-2018-03-15T14:22:46Z.xml")
+	writeFixture(t, "failures.xml", tmpDir, "test-results-2018-03-14T14:22:46Z.xml")
+
+	charts := viewer.SVGCharts{
+		ResultsDir: tmpDir,
+	}
+
+	err = charts.WriteReport([]models.Summary{}, loadRuns(t, tmpDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for filename, expected := range map[string][]string{
+		"pass-rate.svg": {
+			"Pass rate",
+			"<title>2018-03-14T14:22:46Z: 0%</title>",
+			"<title>2018-03-15T14:22:46Z: 100%</title>",
+			"<polyline",
+		},
+		"duration.svg": {
+			"Total duration",
+			"<title>2018-03-15T14:22:46Z: 9.8s</title>",
+		},
+		"failures.svg": {
+			"Failures per run",
+			"<title>2018-03-14T14:22:46Z: 8</title></rect>",
+			"<title>2018-03-15T14:22:46Z: 0</title></rect>",
+		},
+	} {
+		contents, err := ioutil.ReadFile(filepath.Join(tmpDir, filename))
+		if err != nil {
+			t.Fatal(err)
+		}
+		output := string(contents)
+
+		var svg struct {
+			XMLName xml.Name
+		}
+		if err = xml.Unmarshal(contents, &svg); err != nil {
+			t.Fatalf("expected '%s' to be valid xml: %s", filename, err)
+		}
+		helpers.AssertEquals(t, svg.XMLName.Local, "svg")
+
+		for _, substring := range expected {
+			if !strings.Contains(output, substring) {
+				t.Fatalf("expected '%s' to contain '%s' but it did not: %s", filename, substring, output)
+			}
+		}
+	}
+}
+
+func TestSVGErrorOnNoRuns(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "svg-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	charts := viewer.SVGCharts{
+		ResultsDir: tmpDir,
+	}
+
+	err = charts.WriteReport([]models.Summary{}, nil)
+	if err == nil {
+		t.Fatal("expected error on no runs but it succeeded")
+	}
+	if !strings.Contains(err.Error(), "found no test results") {
+		t.Fatalf("expected error to contain 'found no test results' but it did not: %s", err.Error())
+	}
+}
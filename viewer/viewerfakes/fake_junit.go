@@ -4,15 +4,17 @@ package viewerfakes
 import (
 	"sync"
 
+	"github.com/ljfranklin/test-runner-resource/history"
 	"github.com/ljfranklin/test-runner-resource/models"
 	"github.com/ljfranklin/test-runner-resource/viewer"
 )
 
 type FakeJunit struct {
-	PrintSummaryStub        func(models.Summary) error
+	PrintSummaryStub        func(models.Summary, []history.Run) error
 	printSummaryMutex       sync.RWMutex
 	printSummaryArgsForCall []struct {
 		arg1 models.Summary
+		arg2 []history.Run
 	}
 	printSummaryReturns struct {
 		result1 error
@@ -24,16 +26,22 @@ type FakeJunit struct {
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *FakeJunit) PrintSummary(arg1 models.Summary) error {
+func (fake *FakeJunit) PrintSummary(arg1 models.Summary, arg2 []history.Run) error {
+	var arg2Copy []history.Run
+	if arg2 != nil {
+		arg2Copy = make([]history.Run, len(arg2))
+		copy(arg2Copy, arg2)
+	}
 	fake.printSummaryMutex.Lock()
 	ret, specificReturn := fake.printSummaryReturnsOnCall[len(fake.printSummaryArgsForCall)]
 	fake.printSummaryArgsForCall = append(fake.printSummaryArgsForCall, struct {
 		arg1 models.Summary
-	}{arg1})
-	fake.recordInvocation("PrintSummary", []interface{}{arg1})
+		arg2 []history.Run
+	}{arg1, arg2Copy})
+	fake.recordInvocation("PrintSummary", []interface{}{arg1, arg2Copy})
 	fake.printSummaryMutex.Unlock()
 	if fake.PrintSummaryStub != nil {
-		return fake.PrintSummaryStub(arg1)
+		return fake.PrintSummaryStub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1
@@ -47,10 +55,10 @@ func (fake *FakeJunit) PrintSummaryCallCount() int {
 	return len(fake.printSummaryArgsForCall)
 }
 
-func (fake *FakeJunit) PrintSummaryArgsForCall(i int) models.Summary {
+func (fake *FakeJunit) PrintSummaryArgsForCall(i int) (models.Summary, []history.Run) {
 	fake.printSummaryMutex.RLock()
 	defer fake.printSummaryMutex.RUnlock()
-	return fake.printSummaryArgsForCall[i].arg1
+	return fake.printSummaryArgsForCall[i].arg1, fake.printSummaryArgsForCall[i].arg2
 }
 
 func (fake *FakeJunit) PrintSummaryReturns(result1 error) {